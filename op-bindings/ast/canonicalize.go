@@ -6,6 +6,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/ethereum-optimism/optimism/op-bindings/solc"
 )
@@ -35,6 +36,68 @@ type typeRemapping struct {
 // search of all replacements when performing substring matches of
 // composite types.
 func CanonicalizeASTIDs(in *solc.StorageLayout, monorepoBase string) *solc.StorageLayout {
+	return canonicalizeASTIDs(in, monorepoBase, typeASTID)
+}
+
+// Canonicalizer canonicalizes the storage layouts of many contracts, such as
+// all the contracts of a single bindings generation run. Type identifiers
+// embed the AST ID of the node that declares them, so the same identifiers
+// show up in every contract built from the same source. The Canonicalizer
+// analyzes each identifier once and reuses the result across contracts. It is
+// safe for concurrent use.
+type Canonicalizer struct {
+	monorepoBase string
+	types        sync.Map // type identifier -> typeMatch
+}
+
+type typeMatch struct {
+	astID string
+	ok    bool
+}
+
+// NewCanonicalizer creates a Canonicalizer that normalizes contract paths
+// relative to monorepoBase.
+func NewCanonicalizer(monorepoBase string) *Canonicalizer {
+	return &Canonicalizer{monorepoBase: monorepoBase}
+}
+
+// Canonicalize returns the same layout as CanonicalizeASTIDs.
+func (c *Canonicalizer) Canonicalize(in *solc.StorageLayout) *solc.StorageLayout {
+	return canonicalizeASTIDs(in, c.monorepoBase, c.typeASTID)
+}
+
+func (c *Canonicalizer) typeASTID(typ string) (string, bool) {
+	if m, ok := c.types.Load(typ); ok {
+		match := m.(typeMatch)
+		return match.astID, match.ok
+	}
+	astID, ok := typeASTID(typ)
+	c.types.Store(typ, typeMatch{astID: astID, ok: ok})
+	return astID, ok
+}
+
+// typeASTID returns the AST ID embedded in a type identifier, and whether the
+// type should be remapped at all.
+func typeASTID(typ string) (string, bool) {
+	matches := remapTypeRe.FindAllStringSubmatch(typ, -1)
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	// The storage types include the size when its a fixed size.
+	// This is subject to breaking in the future if a type with
+	// an ast id is added in a fixed storage type. We don't want
+	// to skip a type with `_storage` in it if it has a subtype
+	// with an ast id or it has an astid itself.
+	skip := len(remapAstIdStorage.FindAllStringSubmatch(typ, -1)) == 0
+	if strings.Contains(typ, "storage") && skip {
+		return "", false
+	}
+
+	return matches[0][2], true
+}
+
+func canonicalizeASTIDs(in *solc.StorageLayout, monorepoBase string, matchType func(string) (string, bool)) *solc.StorageLayout {
 	lastId := uint(1000)
 	astIDRemappings := make(map[uint]uint)
 	typeRemappings := make(map[string]string)
@@ -58,22 +121,11 @@ func CanonicalizeASTIDs(in *solc.StorageLayout, monorepoBase string) *solc.Stora
 			continue
 		}
 
-		matches := remapTypeRe.FindAllStringSubmatch(oldType, -1)
-		if len(matches) == 0 {
-			continue
-		}
-
-		// The storage types include the size when its a fixed size.
-		// This is subject to breaking in the future if a type with
-		// an ast id is added in a fixed storage type. We don't want
-		// to skip a type with `_storage` in it if it has a subtype
-		// with an ast id or it has an astid itself.
-		skip := len(remapAstIdStorage.FindAllStringSubmatch(oldType, -1)) == 0
-		if strings.Contains(oldType, "storage") && skip {
+		replaceAstID, ok := matchType(oldType)
+		if !ok {
 			continue
 		}
 
-		replaceAstID := matches[0][2]
 		newType := strings.Replace(oldType, replaceAstID, strconv.Itoa(int(lastId)), 1)
 		typeRemappings[oldType] = newType
 		lastId++
@@ -15,36 +15,42 @@ type astIDTest struct {
 	Out *solc.StorageLayout `json:"out"`
 }
 
+var canonicalizeTests = []struct {
+	name     string
+	filename string
+}{
+	{
+		"simple",
+		"simple.json",
+	},
+	{
+		"remap public variables",
+		"public-variables.json",
+	},
+	{
+		"values in storage",
+		"values-in-storage.json",
+	},
+	{
+		"custom types",
+		"custom-types.json",
+	},
+}
+
+func readAstIDTest(t testing.TB, filename string) astIDTest {
+	f, err := os.Open(path.Join("testdata", filename))
+	require.NoError(t, err)
+	dec := json.NewDecoder(f)
+	var testData astIDTest
+	require.NoError(t, dec.Decode(&testData))
+	require.NoError(t, f.Close())
+	return testData
+}
+
 func TestCanonicalize(t *testing.T) {
-	tests := []struct {
-		name     string
-		filename string
-	}{
-		{
-			"simple",
-			"simple.json",
-		},
-		{
-			"remap public variables",
-			"public-variables.json",
-		},
-		{
-			"values in storage",
-			"values-in-storage.json",
-		},
-		{
-			"custom types",
-			"custom-types.json",
-		},
-	}
-	for _, tt := range tests {
+	for _, tt := range canonicalizeTests {
 		t.Run(tt.name, func(t *testing.T) {
-			f, err := os.Open(path.Join("testdata", tt.filename))
-			require.NoError(t, err)
-			dec := json.NewDecoder(f)
-			var testData astIDTest
-			require.NoError(t, dec.Decode(&testData))
-			require.NoError(t, f.Close())
+			testData := readAstIDTest(t, tt.filename)
 
 			// Run 100 times to make sure that we aren't relying
 			// on random map iteration order.
@@ -54,3 +60,50 @@ func TestCanonicalize(t *testing.T) {
 		})
 	}
 }
+
+func TestCanonicalizer(t *testing.T) {
+	c := NewCanonicalizer("")
+	for _, tt := range canonicalizeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			testData := readAstIDTest(t, tt.filename)
+
+			// The cached result must be identical to the uncached one.
+			for i := 0; i < 2; i++ {
+				require.Equal(t, testData.Out, c.Canonicalize(testData.In))
+			}
+		})
+	}
+}
+
+// benchmarkLayouts returns the testdata layouts repeated as if many contracts
+// shared the same types, as happens for contracts built from the same sources.
+func benchmarkLayouts(b *testing.B) []*solc.StorageLayout {
+	var layouts []*solc.StorageLayout
+	for i := 0; i < 25; i++ {
+		for _, tt := range canonicalizeTests {
+			layouts = append(layouts, readAstIDTest(b, tt.filename).In)
+		}
+	}
+	return layouts
+}
+
+func BenchmarkCanonicalizeASTIDs(b *testing.B) {
+	layouts := benchmarkLayouts(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, layout := range layouts {
+			CanonicalizeASTIDs(layout, "")
+		}
+	}
+}
+
+func BenchmarkCanonicalizer(b *testing.B) {
+	layouts := benchmarkLayouts(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c := NewCanonicalizer("")
+		for _, layout := range layouts {
+			c.Canonicalize(layout)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// astIDKeys are the keys of AST nodes whose values are AST IDs, or lists of
+// AST IDs.
+var astIDKeys = map[string]bool{
+	"id":                      true,
+	"referencedDeclaration":   true,
+	"scope":                   true,
+	"sourceUnit":              true,
+	"baseFunctions":           true,
+	"contractDependencies":    true,
+	"linearizedBaseContracts": true,
+	"usedErrors":              true,
+	"usedEvents":              true,
+}
+
+// typeIdentifierASTIDRe matches the AST IDs that solc embeds in type
+// identifiers, such as t_struct$_Checkpoint_$1011_storage_ptr. Only the types
+// that refer to a declaration embed its ID, the lengths of fixed size arrays
+// such as t_array$_t_uint256_$10_storage are left alone.
+var typeIdentifierASTIDRe = regexp.MustCompile(`(t_(?:struct|enum|contract|super|userDefinedValueType)\$_[^$]*_\$|t_module_)(\d+)`)
+
+// sourceLocationKeys are the keys of AST nodes whose values are source
+// locations, or lists of source locations, of the form start:length:index.
+var sourceLocationKeys = map[string]bool{
+	"src":           true,
+	"nameLocation":  true,
+	"nameLocations": true,
+}
+
+// CanonicalizeAST canonicalizes the AST IDs of the solc AST of a contract, in
+// the same spirit as the storage layouts, so that the AST only changes when
+// the source does:
+//
+//   - The IDs of the nodes of the source unit are renumbered from 1, walking
+//     the tree depth first and visiting the keys of each node in sorted order.
+//   - The IDs of declarations in other source units, which the source unit
+//     refers to through imports, inheritance or types, are numbered after
+//     them in the order they are first referred to in the same walk.
+//   - The source indices of source locations are renumbered from 0 in the
+//     same way, and absolute paths are made relative to the monorepo base.
+//
+// Negative IDs, which refer to global declarations such as require, are kept.
+func (c *Canonicalizer) CanonicalizeAST(raw json.RawMessage) (json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var tree any
+	if err := dec.Decode(&tree); err != nil {
+		return nil, fmt.Errorf("cannot decode AST: %w", err)
+	}
+
+	ids := &astIDs{local: make(map[string]string), foreign: make(map[string]string), sources: make(map[string]string)}
+	collectASTIDs(tree, ids.local)
+	tree = c.remapASTIDs(tree, "", ids)
+
+	out, err := json.Marshal(tree)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode AST: %w", err)
+	}
+	return out, nil
+}
+
+// astIDs holds the canonical IDs of a single AST.
+type astIDs struct {
+	// local holds the IDs of the nodes of the AST.
+	local map[string]string
+	// foreign holds the IDs of the declarations of other source units that
+	// the AST refers to.
+	foreign map[string]string
+	// sources holds the source indices of the source locations.
+	sources map[string]string
+}
+
+// remap returns the canonical ID of id. An ID that is not the ID of a node of
+// the AST refers to another source unit, and is assigned the next ID after
+// the IDs of the nodes.
+func (ids *astIDs) remap(id string) string {
+	if remapped, ok := ids.local[id]; ok {
+		return remapped
+	}
+	if strings.HasPrefix(id, "-") {
+		return id
+	}
+	remapped, ok := ids.foreign[id]
+	if !ok {
+		remapped = strconv.Itoa(len(ids.local) + len(ids.foreign) + 1)
+		ids.foreign[id] = remapped
+	}
+	return remapped
+}
+
+// remapSourceLocation replaces the source index of a source location, such as
+// 120:5:3. Locations without a source have index -1, which is kept.
+func (ids *astIDs) remapSourceLocation(loc string) string {
+	i := strings.LastIndex(loc, ":")
+	if i < 0 || strings.HasPrefix(loc[i+1:], "-") {
+		return loc
+	}
+	index := loc[i+1:]
+	remapped, ok := ids.sources[index]
+	if !ok {
+		remapped = strconv.Itoa(len(ids.sources))
+		ids.sources[index] = remapped
+	}
+	return loc[:i+1] + remapped
+}
+
+// collectASTIDs assigns a canonical ID to the ID of every node, visiting the
+// keys of each node in sorted order so that the result is deterministic.
+func collectASTIDs(v any, remappings map[string]string) {
+	switch t := v.(type) {
+	case map[string]any:
+		if id, ok := t["id"].(json.Number); ok {
+			if _, seen := remappings[id.String()]; !seen {
+				remappings[id.String()] = strconv.Itoa(len(remappings) + 1)
+			}
+		}
+		for _, k := range sortedKeys(t) {
+			collectASTIDs(t[k], remappings)
+		}
+	case []any:
+		for _, child := range t {
+			collectASTIDs(child, remappings)
+		}
+	}
+}
+
+// remapASTIDs replaces the AST IDs and source indices in v, which is the value
+// of key in its parent node. The keys of each node are visited in sorted order,
+// the same order as collectASTIDs, so that the IDs of other source units are
+// assigned deterministically.
+func (c *Canonicalizer) remapASTIDs(v any, key string, ids *astIDs) any {
+	switch t := v.(type) {
+	case map[string]any:
+		if key == "internalFunctionIDs" {
+			// Internal function IDs are keyed by the AST ID of the function.
+			remapped := make(map[string]any, len(t))
+			for _, k := range sortedKeys(t) {
+				remapped[ids.remap(k)] = t[k]
+			}
+			return remapped
+		}
+		for _, k := range sortedKeys(t) {
+			childKey := k
+			if key == "exportedSymbols" {
+				// Exported symbols map names to lists of IDs.
+				childKey = "id"
+			}
+			t[k] = c.remapASTIDs(t[k], childKey, ids)
+		}
+	case []any:
+		for i, child := range t {
+			t[i] = c.remapASTIDs(child, key, ids)
+		}
+	case json.Number:
+		if astIDKeys[key] {
+			return json.Number(ids.remap(t.String()))
+		}
+	case string:
+		switch {
+		case key == "typeIdentifier":
+			return typeIdentifierASTIDRe.ReplaceAllStringFunc(t, func(m string) string {
+				sub := typeIdentifierASTIDRe.FindStringSubmatch(m)
+				return sub[1] + ids.remap(sub[2])
+			})
+		case sourceLocationKeys[key]:
+			return ids.remapSourceLocation(t)
+		case key == "absolutePath":
+			if filepath.IsAbs(t) {
+				return strings.TrimPrefix(strings.Replace(t, c.monorepoBase, "", 1), "/")
+			}
+		}
+	}
+	return v
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
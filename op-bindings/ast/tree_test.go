@@ -0,0 +1,156 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalizeAST(t *testing.T) {
+	in := `{
+		"absolutePath": "/monorepo/src/Foo.sol",
+		"exportedSymbols": {"Foo": [52]},
+		"id": 53,
+		"nodeType": "SourceUnit",
+		"nodes": [{
+			"id": 52,
+			"nodeType": "ContractDefinition",
+			"linearizedBaseContracts": [52],
+			"scope": 53,
+			"nodes": [{
+				"id": 40,
+				"nodeType": "VariableDeclaration",
+				"typeDescriptions": {"typeIdentifier": "t_struct$_Checkpoint_$40_storage", "typeString": "struct Foo.Checkpoint"}
+			}, {
+				"expression": {"id": 41, "referencedDeclaration": -15, "nodeType": "Identifier"},
+				"id": 42,
+				"src": "120:5:0"
+			}]
+		}]
+	}`
+	expected := `{
+		"absolutePath": "src/Foo.sol",
+		"exportedSymbols": {"Foo": [2]},
+		"id": 1,
+		"nodeType": "SourceUnit",
+		"nodes": [{
+			"id": 2,
+			"nodeType": "ContractDefinition",
+			"linearizedBaseContracts": [2],
+			"scope": 1,
+			"nodes": [{
+				"id": 3,
+				"nodeType": "VariableDeclaration",
+				"typeDescriptions": {"typeIdentifier": "t_struct$_Checkpoint_$3_storage", "typeString": "struct Foo.Checkpoint"}
+			}, {
+				"expression": {"id": 5, "referencedDeclaration": -15, "nodeType": "Identifier"},
+				"id": 4,
+				"src": "120:5:0"
+			}]
+		}]
+	}`
+
+	c := NewCanonicalizer("/monorepo")
+	for i := 0; i < 10; i++ {
+		out, err := c.CanonicalizeAST(json.RawMessage(in))
+		require.NoError(t, err)
+		require.JSONEq(t, expected, string(out))
+	}
+
+	_, err := c.CanonicalizeAST(json.RawMessage(`{"id":`))
+	require.ErrorContains(t, err, "cannot decode AST")
+}
+
+func TestCanonicalizeASTCrossUnitReferences(t *testing.T) {
+	// Foo.sol imports Base.sol and inherits from Base. Ln are the IDs of the
+	// nodes of Foo.sol, Fn the IDs of Base.sol, and S the source index of
+	// Foo.sol, which all depend on the other files of the build.
+	unit := `{
+		"absolutePath": "/monorepo/src/Foo.sol",
+		"exportedSymbols": {"Base": [F2], "Foo": [L3]},
+		"id": L4,
+		"nodeType": "SourceUnit",
+		"src": "0:300:S",
+		"nodes": [{
+			"absolutePath": "/monorepo/src/Base.sol",
+			"id": L1,
+			"nodeType": "ImportDirective",
+			"scope": L4,
+			"sourceUnit": F3,
+			"src": "0:20:S"
+		}, {
+			"baseContracts": [{"baseName": {"id": L5, "referencedDeclaration": F2, "src": "37:4:S"}, "id": L2}],
+			"id": L3,
+			"linearizedBaseContracts": [L3, F2],
+			"name": "Foo",
+			"nameLocation": "30:3:S",
+			"nodeType": "ContractDefinition",
+			"nodes": [{
+				"id": L6,
+				"nodeType": "VariableDeclaration",
+				"scope": L3,
+				"typeDescriptions": {"typeIdentifier": "t_array$_t_contract$_Base_$F2_$10_storage", "typeString": "contract Base[10]"}
+			}],
+			"scope": L4,
+			"src": "21:200:S"
+		}]
+	}`
+	expected := `{
+		"absolutePath": "src/Foo.sol",
+		"exportedSymbols": {"Base": [7], "Foo": [3]},
+		"id": 1,
+		"nodeType": "SourceUnit",
+		"src": "0:300:0",
+		"nodes": [{
+			"absolutePath": "src/Base.sol",
+			"id": 2,
+			"nodeType": "ImportDirective",
+			"scope": 1,
+			"sourceUnit": 8,
+			"src": "0:20:0"
+		}, {
+			"baseContracts": [{"baseName": {"id": 5, "referencedDeclaration": 7, "src": "37:4:0"}, "id": 4}],
+			"id": 3,
+			"linearizedBaseContracts": [3, 7],
+			"name": "Foo",
+			"nameLocation": "30:3:0",
+			"nodeType": "ContractDefinition",
+			"nodes": [{
+				"id": 6,
+				"nodeType": "VariableDeclaration",
+				"scope": 3,
+				"typeDescriptions": {"typeIdentifier": "t_array$_t_contract$_Base_$7_$10_storage", "typeString": "contract Base[10]"}
+			}],
+			"scope": 1,
+			"src": "21:200:0"
+		}]
+	}`
+
+	idRe := regexp.MustCompile(`([LF])(\d+)|:S"`)
+	c := NewCanonicalizer("/monorepo")
+	for _, offsets := range []struct{ local, foreign, source int }{
+		// The IDs of Base.sol overlap the canonical IDs of Foo.sol.
+		{local: 900, foreign: 0, source: 0},
+		{local: 100, foreign: 20, source: 3},
+		{local: 1000, foreign: 2000, source: 7},
+	} {
+		in := idRe.ReplaceAllStringFunc(unit, func(m string) string {
+			if m == `:S"` {
+				return fmt.Sprintf(`:%d"`, offsets.source)
+			}
+			id, err := strconv.Atoi(m[1:])
+			require.NoError(t, err)
+			if m[0] == 'L' {
+				return strconv.Itoa(offsets.local + id)
+			}
+			return strconv.Itoa(offsets.foreign + id)
+		})
+		out, err := c.CanonicalizeAST(json.RawMessage(in))
+		require.NoError(t, err)
+		require.JSONEq(t, expected, string(out), "offsets %+v", offsets)
+	}
+}
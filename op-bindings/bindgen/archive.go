@@ -0,0 +1,126 @@
+package bindgen
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// isArchive reports whether the forge artifacts path is an archive rather
+// than a directory, based on its extension.
+func isArchive(path string) bool {
+	return strings.HasSuffix(path, ".zip") || strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz")
+}
+
+// extractArchive extracts the zip or gzipped tar archive at archivePath into
+// dest and returns the number of files extracted. Modification times are kept
+// so that up to date bindings are still detected.
+func extractArchive(archivePath, dest string) (int, error) {
+	if strings.HasSuffix(archivePath, ".zip") {
+		return extractZip(archivePath, dest)
+	}
+	return extractTarGz(archivePath, dest)
+}
+
+func extractZip(archivePath, dest string) (int, error) {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return 0, fmt.Errorf("error opening forge artifacts archive %s: %w", archivePath, err)
+	}
+	defer r.Close()
+
+	count := 0
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return 0, fmt.Errorf("error reading %s from %s: %w", f.Name, archivePath, err)
+		}
+		err = extractFile(dest, f.Name, f.Modified, rc)
+		rc.Close()
+		if err != nil {
+			return 0, fmt.Errorf("error extracting %s from %s: %w", f.Name, archivePath, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+func extractTarGz(archivePath, dest string) (int, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return 0, fmt.Errorf("error opening forge artifacts archive %s: %w", archivePath, err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return 0, fmt.Errorf("error opening forge artifacts archive %s: %w", archivePath, err)
+	}
+	defer gz.Close()
+
+	count := 0
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		} else if err != nil {
+			return 0, fmt.Errorf("error reading forge artifacts archive %s: %w", archivePath, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := extractFile(dest, hdr.Name, hdr.ModTime, tr); err != nil {
+			return 0, fmt.Errorf("error extracting %s from %s: %w", hdr.Name, archivePath, err)
+		}
+		count++
+	}
+}
+
+// extractFile writes the archive entry name to dest. Entries that would end up
+// outside of dest are rejected.
+func extractFile(dest, name string, modTime time.Time, r io.Reader) error {
+	name = filepath.FromSlash(name)
+	if !filepath.IsLocal(name) {
+		return fmt.Errorf("entry path %q is outside of the archive", name)
+	}
+	target := filepath.Join(dest, name)
+	if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(target, modTime, modTime)
+}
+
+// archiveRoot returns the directory holding the artifacts extracted to dir.
+// Archives often wrap the artifacts in a single top-level directory, which is
+// skipped so that the standard <name>.sol/<name>.json layout is found.
+func archiveRoot(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 1 && entries[0].IsDir() && !strings.HasSuffix(entries[0].Name(), ".sol") {
+		return filepath.Join(dir, entries[0].Name()), nil
+	}
+	return dir, nil
+}
@@ -0,0 +1,96 @@
+package bindgen
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestZip(t *testing.T, path string, files map[string]string, modTime time.Time) {
+	t.Helper()
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.CreateHeader(&zip.FileHeader{Name: name, Modified: modTime, Method: zip.Deflate})
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+}
+
+func writeTestTarGz(t *testing.T, path string, files map[string]string, modTime time.Time) {
+	t.Helper()
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	w := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, w.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(content)), ModTime: modTime, Typeflag: tar.TypeReg}))
+		_, err := w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, gz.Close())
+}
+
+func TestExtractArchive(t *testing.T) {
+	modTime := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	files := map[string]string{
+		"forge-artifacts/Foo.sol/Foo.json":        `{"abi":[]}`,
+		"forge-artifacts/Bar.sol/Bar.0.8.15.json": `{"abi":[]}`,
+	}
+
+	for _, archive := range []string{"artifacts.zip", "artifacts.tar.gz", "artifacts.tgz"} {
+		t.Run(archive, func(t *testing.T) {
+			dir := t.TempDir()
+			archivePath := filepath.Join(dir, archive)
+			if filepath.Ext(archive) == ".zip" {
+				writeTestZip(t, archivePath, files, modTime)
+			} else {
+				writeTestTarGz(t, archivePath, files, modTime)
+			}
+			require.True(t, isArchive(archivePath))
+
+			dest := filepath.Join(dir, "out")
+			count, err := extractArchive(archivePath, dest)
+			require.NoError(t, err)
+			require.Equal(t, 2, count)
+
+			root, err := archiveRoot(dest)
+			require.NoError(t, err)
+			require.Equal(t, filepath.Join(dest, "forge-artifacts"), root)
+
+			info, err := os.Stat(filepath.Join(root, "Foo.sol", "Foo.json"))
+			require.NoError(t, err)
+			require.True(t, info.ModTime().Equal(modTime))
+		})
+	}
+}
+
+func TestExtractArchiveRejectsEscapingPaths(t *testing.T) {
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "artifacts.zip")
+	writeTestZip(t, archivePath, map[string]string{"../Foo.sol/Foo.json": "{}"}, time.Now())
+
+	_, err := extractArchive(archivePath, filepath.Join(dir, "out"))
+	require.ErrorContains(t, err, "is outside of the archive")
+	require.NoFileExists(t, filepath.Join(dir, "Foo.sol", "Foo.json"))
+}
+
+func TestArchiveRoot(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "Foo.sol"), 0o700))
+	root, err := archiveRoot(dir)
+	require.NoError(t, err)
+	require.Equal(t, dir, root, "a single contract directory is not a wrapper")
+}
@@ -0,0 +1,172 @@
+package bindgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/foundry"
+)
+
+// DefaultCompilerVersionPattern is the default CompilerVersionPattern.
+const DefaultCompilerVersionPattern = foundry.DefaultCompilerVersionPattern
+
+// artifactMetadata is the subset of the solc metadata that foundry includes
+// in an artifact when the metadata extra output is enabled.
+type artifactMetadata struct {
+	Compiler metadataCompiler `json:"compiler"`
+	Settings metadataSettings `json:"settings"`
+}
+
+type metadataCompiler struct {
+	Version string `json:"version"`
+}
+
+type metadataSettings struct {
+	Optimizer  metadataOptimizer `json:"optimizer"`
+	EVMVersion string            `json:"evmVersion"`
+}
+
+type metadataOptimizer struct {
+	Enabled bool `json:"enabled"`
+	Runs    int  `json:"runs"`
+}
+
+// artifactData is a forge or raw artifact decoded in a single pass. The
+// fields whose shape differs between forge and raw artifacts, or that are only
+// needed for some contracts, are kept encoded.
+type artifactData struct {
+	Abi              json.RawMessage `json:"abi"`
+	StorageLayout    json.RawMessage `json:"storageLayout"`
+	Bytecode         json.RawMessage `json:"bytecode"`
+	DeployedBytecode json.RawMessage `json:"deployedBytecode"`
+	// BytecodeRuntime is the deployed bytecode in the combined JSON output of
+	// the Vyper compiler.
+	BytecodeRuntime json.RawMessage `json:"bytecode_runtime"`
+	Metadata        json.RawMessage `json:"metadata"`
+	// RawMetadata is the solc metadata as the JSON string that its hash is
+	// embedded in the deployed bytecode of.
+	RawMetadata string          `json:"rawMetadata"`
+	UserDoc     userDoc         `json:"userdoc"`
+	DevDoc      devDoc          `json:"devdoc"`
+	Ast         json.RawMessage `json:"ast"`
+}
+
+// isRawBytecode reports whether the bytecode of an artifact is a hex string,
+// as in raw artifacts that only hold an ABI and bytecode, rather than a forge
+// bytecode object.
+func isRawBytecode(bytecode json.RawMessage) bool {
+	return len(bytecode) > 0 && bytecode[0] == '"'
+}
+
+// isRaw reports whether the artifact is a raw artifact.
+func (a *artifactData) isRaw() bool {
+	return isRawBytecode(a.Bytecode)
+}
+
+// hasStorageLayout reports whether the artifact was built with the
+// storageLayout extra output, as opposed to a contract without storage.
+func (a *artifactData) hasStorageLayout() bool {
+	return !isNull(a.StorageLayout)
+}
+
+// metadata decodes the solc metadata of the artifact, which is empty when the
+// artifact was built without the metadata extra output.
+func (a *artifactData) metadata() (artifactMetadata, error) {
+	var metadata artifactMetadata
+	if isNull(a.Metadata) {
+		return metadata, nil
+	}
+	if err := json.Unmarshal(a.Metadata, &metadata); err != nil {
+		return artifactMetadata{}, fmt.Errorf("invalid metadata: %w", err)
+	}
+	return metadata, nil
+}
+
+// artifact returns the artifact as a foundry.Artifact, without its storage
+// layout, which is decoded separately.
+func (a *artifactData) artifact() (foundry.Artifact, error) {
+	artifact := foundry.Artifact{Abi: a.Abi}
+	if !a.isRaw() {
+		if !isNull(a.Bytecode) {
+			if err := json.Unmarshal(a.Bytecode, &artifact.Bytecode); err != nil {
+				return foundry.Artifact{}, fmt.Errorf("invalid bytecode: %w", err)
+			}
+		}
+		if !isNull(a.DeployedBytecode) {
+			if err := json.Unmarshal(a.DeployedBytecode, &artifact.DeployedBytecode); err != nil {
+				return foundry.Artifact{}, fmt.Errorf("invalid deployed bytecode: %w", err)
+			}
+		}
+		return artifact, nil
+	}
+
+	if err := json.Unmarshal(a.Bytecode, &artifact.Bytecode.Object); err != nil {
+		return foundry.Artifact{}, fmt.Errorf("invalid bytecode: %w", err)
+	}
+	deployed := a.DeployedBytecode
+	if isNull(deployed) {
+		deployed = a.BytecodeRuntime
+	}
+	if !isNull(deployed) {
+		if err := json.Unmarshal(deployed, &artifact.DeployedBytecode.Object); err != nil {
+			return foundry.Artifact{}, fmt.Errorf("invalid deployed bytecode: %w", err)
+		}
+	}
+	return artifact, nil
+}
+
+// isNull reports whether an encoded field is missing or null.
+func isNull(field json.RawMessage) bool {
+	return len(field) == 0 || bytes.Equal(field, []byte("null"))
+}
+
+// decodeArtifact decodes a forge artifact, or a raw artifact when its
+// bytecode is a hex string rather than an object.
+func decodeArtifact(data []byte) (*artifactData, error) {
+	var a artifactData
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// resolveArtifactPath returns the path to the forge artifact of an entry of
+// the contracts list. Entries with a file are read from that file's artifacts
+// directory, the others are resolved by foundry.ArtifactsDir.Resolve. Other
+// contracts that share the name of the artifact are ignored with a warning,
+// unless Strict is set.
+func (g *generator) resolveArtifactPath(id string) (string, error) {
+	if file := g.artifactFiles[id]; file != "" {
+		_, name := foundry.ParseContractID(id)
+		return g.artifacts.ResolveInFile(name, file)
+	}
+	artifactPath, ignored, err := g.artifacts.Resolve(id)
+	if err != nil {
+		return "", err
+	}
+	if len(ignored) == 0 {
+		return artifactPath, nil
+	}
+	if g.Strict {
+		return "", fmt.Errorf("forge-artifact %s of %q collides with %s", artifactPath, id, strings.Join(ignored, ", "))
+	}
+	g.logger.Warn("Other contracts with the same name are ignored", "contract", id, "path", artifactPath, "ignored", strings.Join(ignored, ", "))
+	return artifactPath, nil
+}
+
+// artifactDisplayPath returns the path of an artifact as it should be shown to
+// users. Artifacts extracted from an archive are shown inside the archive
+// rather than in the temp dir they were extracted to.
+func (g *generator) artifactDisplayPath(artifactPath string) string {
+	if g.artifactsDir == g.ForgeArtifacts {
+		return artifactPath
+	}
+	rel, err := filepath.Rel(g.artifactsDir, artifactPath)
+	if err != nil {
+		return artifactPath
+	}
+	return filepath.Join(g.ForgeArtifacts, rel)
+}
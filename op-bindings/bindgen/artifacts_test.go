@@ -0,0 +1,120 @@
+package bindgen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/foundry"
+	"github.com/ethereum-optimism/optimism/op-service/testlog"
+)
+
+// writeTestArtifact writes a minimal forge artifact compiled from sourcePath
+// to path, relative to dir.
+func writeTestArtifact(t *testing.T, dir, path, sourcePath, name string) {
+	t.Helper()
+	artifact := fmt.Sprintf(`{"abi":[],"metadata":{"settings":{"compilationTarget":{%q:%q}}}}`, sourcePath, name)
+	full := filepath.Join(dir, path)
+	require.NoError(t, os.MkdirAll(filepath.Dir(full), 0o700))
+	require.NoError(t, os.WriteFile(full, []byte(artifact), 0o600))
+}
+
+func TestResolveArtifactPath(t *testing.T) {
+	dir := t.TempDir()
+	writeTestArtifact(t, dir, "Foo.sol/Foo.json", "src/Foo.sol", "Foo")
+	writeTestArtifact(t, dir, "Baz.sol/Baz.json", "src/Baz.sol", "Baz")
+	writeTestArtifact(t, dir, "Baz.sol/Baz.1.2.3.json", "src/Other.sol", "Baz")
+	writeTestArtifact(t, dir, "Types.sol/Withdrawal.json", "src/Types.sol", "Withdrawal")
+	artifactFiles := map[string]string{"Withdrawal": "Types.sol", "Misplaced": "Other.sol"}
+
+	artifacts, err := foundry.ScanArtifacts(dir, regexp.MustCompile(DefaultCompilerVersionPattern))
+	require.NoError(t, err)
+	logger := testlog.Logger(t, log.LvlInfo)
+	logs := testlog.Capture(logger)
+	g := &generator{logger: logger, artifacts: artifacts, artifactFiles: artifactFiles}
+
+	artifactPath, err := g.resolveArtifactPath("Foo")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "Foo.sol/Foo.json"), artifactPath)
+
+	artifactPath, err = g.resolveArtifactPath("Withdrawal")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "Types.sol/Withdrawal.json"), artifactPath)
+	_, err = g.resolveArtifactPath("Misplaced")
+	require.ErrorContains(t, err, `cannot find forge-artifact of "Misplaced" in `+filepath.Join(dir, "Other.sol"))
+
+	artifactPath, err = g.resolveArtifactPath("Baz")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "Baz.sol/Baz.json"), artifactPath)
+	require.NotNil(t, logs.FindLog(log.LvlWarn, "Other contracts with the same name are ignored"))
+
+	g.Strict = true
+	_, err = g.resolveArtifactPath("Baz")
+	require.ErrorContains(t, err, "collides with "+filepath.Join(dir, "Baz.sol/Baz.1.2.3.json")+" (src/Other.sol:Baz)")
+}
+
+// decodeTestArtifact decodes an artifact and returns it along with whether it
+// is a raw artifact.
+func decodeTestArtifact(t *testing.T, data string) (foundry.Artifact, bool) {
+	t.Helper()
+	decoded, err := decodeArtifact([]byte(data))
+	require.NoError(t, err)
+	artifact, err := decoded.artifact()
+	require.NoError(t, err)
+	return artifact, decoded.isRaw()
+}
+
+func TestDecodeArtifact(t *testing.T) {
+	t.Run("forge", func(t *testing.T) {
+		data := `{"abi":[],"bytecode":{"object":"0x6001"},"deployedBytecode":{"object":"0x6002"},"storageLayout":{"storage":[],"types":{}},"metadata":{"compiler":{"version":"0.8.15"}}}`
+		artifact, raw := decodeTestArtifact(t, data)
+		require.False(t, raw)
+		require.Equal(t, hexutil.Bytes{0x60, 0x01}, artifact.Bytecode.Object)
+		require.Equal(t, hexutil.Bytes{0x60, 0x02}, artifact.DeployedBytecode.Object)
+
+		decoded, err := decodeArtifact([]byte(data))
+		require.NoError(t, err)
+		metadata, err := decoded.metadata()
+		require.NoError(t, err)
+		require.Equal(t, "0.8.15", metadata.Compiler.Version)
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		artifact, raw := decodeTestArtifact(t, `{"abi":[],"bytecode":"0x6001","deployedBytecode":"0x6002"}`)
+		require.True(t, raw)
+		require.JSONEq(t, `[]`, string(artifact.Abi))
+		require.Equal(t, hexutil.Bytes{0x60, 0x01}, artifact.Bytecode.Object)
+		require.Equal(t, hexutil.Bytes{0x60, 0x02}, artifact.DeployedBytecode.Object)
+	})
+
+	t.Run("vyper combined json", func(t *testing.T) {
+		artifact, raw := decodeTestArtifact(t, `{"abi":[],"bytecode":"0x6001","bytecode_runtime":"0x6002"}`)
+		require.True(t, raw)
+		require.Equal(t, hexutil.Bytes{0x60, 0x02}, artifact.DeployedBytecode.Object)
+	})
+
+	t.Run("storage layout presence", func(t *testing.T) {
+		for data, expected := range map[string]bool{
+			`{"storageLayout":{"storage":[],"types":{}}}`: true,
+			`{"storageLayout":null}`:                      false,
+			`{"abi":[]}`:                                  false,
+		} {
+			decoded, err := decodeArtifact([]byte(data))
+			require.NoError(t, err)
+			require.Equal(t, expected, decoded.hasStorageLayout(), data)
+		}
+	})
+
+	t.Run("invalid raw bytecode", func(t *testing.T) {
+		decoded, err := decodeArtifact([]byte(`{"abi":[],"bytecode":"6001"}`))
+		require.NoError(t, err)
+		_, err = decoded.artifact()
+		require.ErrorContains(t, err, "invalid bytecode")
+	})
+}
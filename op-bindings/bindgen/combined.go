@@ -0,0 +1,108 @@
+package bindgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// combinedMetadataFile is the name of the file the metadata of every contract
+// is written to when LocalConfig.Combined is set.
+const combinedMetadataFile = "bindings_more.go"
+
+// combinedMetadata collects the template data of every contract so that it
+// can be written to a single file once all contracts are processed. It is safe
+// for concurrent use.
+type combinedMetadata struct {
+	mu        sync.Mutex
+	contracts []data
+}
+
+func (c *combinedMetadata) add(d data) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.contracts = append(c.contracts, d)
+}
+
+// writeCombinedMetadata executes the metadata template once with the metadata
+// of every contract, sorted by name so that the output is deterministic.
+func (g *generator) writeCombinedMetadata() error {
+	g.combined.mu.Lock()
+	contracts := append([]data(nil), g.combined.contracts...)
+	g.combined.mu.Unlock()
+	sort.Slice(contracts, func(i, j int) bool {
+		return contracts[i].Name < contracts[j].Name
+	})
+
+	metadataFile := filepath.Join(g.OutDir, combinedMetadataFile)
+	var metadata bytes.Buffer
+	d := combinedData{Package: g.Package, Contracts: contracts}
+	for _, contract := range contracts {
+		if contract.DeployedSourceMapFile != "" {
+			d.EmbedsSourceMaps = true
+		}
+	}
+	if err := g.tmpl.Execute(&metadata, d); err != nil {
+		return fmt.Errorf("error writing template %s: %w", metadataFile, err)
+	}
+	if err := checkDuplicateDecls(metadata.Bytes()); err != nil {
+		return fmt.Errorf("error writing template %s: %w", metadataFile, err)
+	}
+	formatted, err := format.Source(metadata.Bytes())
+	if err != nil {
+		return fmt.Errorf("error formatting %s: %w", metadataFile, err)
+	}
+	return g.writeOutput(metadataFile, formatted)
+}
+
+// checkDuplicateDecls returns an error when src declares the same top-level
+// identifier more than once, which happens when the identifiers a template
+// derives from two contract names collide. init functions and methods are
+// ignored since they can be declared several times.
+func checkDuplicateDecls(src []byte) error {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return fmt.Errorf("generated metadata is not valid Go: %w", err)
+	}
+	seen := make(map[string]bool)
+	var duplicates []string
+	declare := func(ident *ast.Ident) {
+		if ident.Name == "_" {
+			return
+		}
+		if seen[ident.Name] {
+			duplicates = append(duplicates, ident.Name)
+		}
+		seen[ident.Name] = true
+	}
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil && decl.Name.Name != "init" {
+				declare(decl.Name)
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						declare(name)
+					}
+				case *ast.TypeSpec:
+					declare(spec.Name)
+				}
+			}
+		}
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("duplicate identifiers: %s", strings.Join(duplicates, ", "))
+	}
+	return nil
+}
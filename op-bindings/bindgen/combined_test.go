@@ -0,0 +1,61 @@
+package bindgen
+
+import (
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimism/op-service/testlog"
+)
+
+func TestWriteCombinedMetadata(t *testing.T) {
+	tmpl, err := loadTemplate("", true)
+	require.NoError(t, err)
+	g := &generator{
+		LocalConfig: LocalConfig{OutDir: t.TempDir(), Package: "bindings", Combined: true},
+		logger:      testlog.Logger(t, log.LvlInfo),
+		tmpl:        tmpl,
+	}
+	g.combined.add(data{Name: "Foo", Contract: "Foo", StorageLayout: "{}", DeployedBin: "0x01", Package: "bindings", Bin: "0x03"})
+	g.combined.add(data{Name: "Bar", Contract: "Bar", StorageLayout: "{}", DeployedBin: "0x02", Package: "bindings", DeployedSourceMap: "1:2:3"})
+	require.NoError(t, g.writeCombinedMetadata())
+
+	out, err := os.ReadFile(filepath.Join(g.OutDir, combinedMetadataFile))
+	require.NoError(t, err)
+	formatted, err := format.Source(out)
+	require.NoError(t, err)
+	require.Equal(t, string(formatted), string(out), "combined metadata is not gofmt-ed")
+	require.Contains(t, string(out), `var BarDeployedSourceMap = "1:2:3"`)
+	require.Contains(t, string(out), `creationBytecodes["Foo"] = FooBin`)
+	require.NotContains(t, string(out), `creationBytecodes["Bar"]`)
+	require.Less(t, strings.Index(string(out), "BarStorageLayoutJSON"), strings.Index(string(out), "FooStorageLayoutJSON"))
+
+	g.combined.add(data{Name: "Foo", Contract: "Foo", StorageLayout: "{}", DeployedBin: "0x01", Package: "bindings"})
+	require.ErrorContains(t, g.writeCombinedMetadata(), "duplicate identifiers: FooStorageLayoutJSON, FooStorageLayout, FooDeployedBin")
+}
+
+func TestCheckDuplicateDecls(t *testing.T) {
+	tests := []struct {
+		src string
+		err string
+	}{
+		{src: "package p\nconst A = 1\nvar B = 2\nfunc init() {}\nfunc init() {}"},
+		{src: "package p\nconst A = 1\nvar A = 2", err: "duplicate identifiers: A"},
+		{src: "package p\ntype T int\nfunc T() {}", err: "duplicate identifiers: T"},
+		{src: "package p\nvar _, _ = 1, 2"},
+		{src: "package p\nvar", err: "not valid Go"},
+	}
+	for _, tt := range tests {
+		err := checkDuplicateDecls([]byte(tt.src))
+		if tt.err != "" {
+			require.ErrorContains(t, err, tt.err, tt.src)
+			continue
+		}
+		require.NoError(t, err, tt.src)
+	}
+}
@@ -0,0 +1,193 @@
+package bindgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/foundry"
+)
+
+// contractEntry is an entry of the contracts list. Entries are either a plain
+// string holding the contract name, or an object holding the name along with
+// per-contract options.
+type contractEntry struct {
+	Name string `json:"name"`
+	// SourceMap includes the deployed source map of the contract in its
+	// metadata, in addition to the contracts listed in SourceMaps.
+	SourceMap bool `json:"sourceMap"`
+	// TypeName overrides the Go type name of the bindings, which defaults to
+	// the contract name. It is also used to name the generated files and the
+	// identifiers of the metadata, while the metadata is still registered
+	// under the contract name, and the other options refer to the contract by
+	// its contract name too.
+	TypeName string `json:"typeName"`
+	// File is the Solidity file the contract is defined in, relative to the
+	// source directory, such as "Types.sol". The artifact is then read from
+	// <file>/<name>.json instead of being looked up by contract name, which
+	// is needed when a file defines several contracts.
+	File string `json:"file"`
+	// Package is the Go package to generate the contract in instead of the
+	// configured one. Its bindings and metadata are written to a directory
+	// named after the package, next to the metadata directory, along with a
+	// registry of the metadata of the package.
+	Package string `json:"package"`
+	// InterfaceOnly only generates the bindings of the contract, without its
+	// bytecode and without a metadata file.
+	InterfaceOnly bool `json:"interfaceOnly"`
+	// Raw only requires the artifact of the contract to have an ABI and
+	// bytecode, as is the case for Vyper contracts. The storage layout is
+	// not canonicalized and the metadata registers an empty layout.
+	// Artifacts whose bytecode is a hex string rather than an object are
+	// always read as raw artifacts.
+	Raw bool `json:"raw"`
+}
+
+// contractName returns the Solidity name of the contract, without the source
+// path of a fully-qualified identifier.
+func (c contractEntry) contractName() string {
+	_, name := foundry.ParseContractID(c.Name)
+	return name
+}
+
+// bindingsName returns the Go type name the bindings of the contract are
+// generated under.
+func (c contractEntry) bindingsName() string {
+	if c.TypeName != "" {
+		return c.TypeName
+	}
+	return c.contractName()
+}
+
+func (c *contractEntry) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*c = contractEntry{Name: name}
+		return nil
+	}
+
+	type entry contractEntry
+	var e entry
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&e); err != nil {
+		return fmt.Errorf("invalid contracts list entry %s: %w", data, err)
+	}
+	if e.Name == "" {
+		return fmt.Errorf("contracts list entry %s has no name", data)
+	}
+	if e.TypeName != "" && (!token.IsIdentifier(e.TypeName) || !token.IsExported(e.TypeName)) {
+		return fmt.Errorf("contracts list entry %s has a typeName that is not an exported Go identifier", data)
+	}
+	if e.Package != "" && !token.IsIdentifier(e.Package) {
+		return fmt.Errorf("contracts list entry %s has a package that is not a Go identifier", data)
+	}
+	if e.File != "" {
+		if !strings.HasSuffix(e.File, ".sol") || !filepath.IsLocal(e.File) {
+			return fmt.Errorf("contracts list entry %s has a file that is not a relative path to a .sol file", data)
+		}
+		if sourcePath, _ := foundry.ParseContractID(e.Name); sourcePath != "" {
+			return fmt.Errorf("contracts list entry %s has both a fully-qualified name and a file", data)
+		}
+	}
+	*c = contractEntry(e)
+	return nil
+}
+
+// readContractsLists reads the contracts lists at the comma-separated paths,
+// where "-" is stdin, and merges them with mergeContractsLists.
+func readContractsLists(paths string, stdin io.Reader) ([]contractEntry, error) {
+	var lists []contractsList
+	for _, path := range strings.Split(paths, ",") {
+		var entries []contractEntry
+		var err error
+		if path == "-" {
+			entries, err = decodeContractsList(stdin)
+			path = "stdin"
+		} else {
+			entries, err = readContractsList(path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		lists = append(lists, contractsList{source: path, entries: entries})
+	}
+	return mergeContractsLists(lists)
+}
+
+// contractsList is a contracts list along with where it was read from.
+type contractsList struct {
+	source  string
+	entries []contractEntry
+}
+
+// mergeContractsLists concatenates contracts lists in order. An entry that is
+// repeated with the same options is only kept the first time, an entry that is
+// repeated with different options is an error.
+func mergeContractsLists(lists []contractsList) ([]contractEntry, error) {
+	type seenEntry struct {
+		entry  contractEntry
+		source string
+	}
+	seen := make(map[string]seenEntry)
+	var merged []contractEntry
+	var conflicts []error
+	for _, list := range lists {
+		for _, entry := range list.entries {
+			prev, ok := seen[entry.Name]
+			if !ok {
+				seen[entry.Name] = seenEntry{entry: entry, source: list.source}
+				merged = append(merged, entry)
+				continue
+			}
+			if prev.entry != entry {
+				conflicts = append(conflicts, fmt.Errorf("%q has different options in %s and %s", entry.Name, prev.source, list.source))
+			}
+		}
+	}
+	if err := errors.Join(conflicts...); err != nil {
+		return nil, fmt.Errorf("error merging contract lists:\n%w", err)
+	}
+	return merged, nil
+}
+
+// readContractsList reads the contracts list at path. The list is decoded one
+// entry at a time so that large lists are not held in memory twice.
+func readContractsList(path string) ([]contractEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading contract list: %w", err)
+	}
+	defer f.Close()
+	return decodeContractsList(f)
+}
+
+func decodeContractsList(r io.Reader) ([]contractEntry, error) {
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing contract list: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, errors.New("error parsing contract list: expected a JSON array")
+	}
+
+	var entries []contractEntry
+	for dec.More() {
+		var entry contractEntry
+		if err := dec.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("error parsing contract list: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("error parsing contract list: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,143 @@
+package bindgen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeContractsList(t *testing.T) {
+	tests := []struct {
+		name     string
+		list     string
+		expected []contractEntry
+		err      string
+	}{
+		{
+			name:     "strings",
+			list:     `["Foo", "src/L1/Bar.sol:Bar"]`,
+			expected: []contractEntry{{Name: "Foo"}, {Name: "src/L1/Bar.sol:Bar"}},
+		},
+		{
+			name:     "objects",
+			list:     `[{"name": "Foo"}, "Bar"]`,
+			expected: []contractEntry{{Name: "Foo"}, {Name: "Bar"}},
+		},
+		{
+			name:     "source maps",
+			list:     `[{"name": "MIPS", "sourceMap": true}, {"name": "Foo", "sourceMap": false}]`,
+			expected: []contractEntry{{Name: "MIPS", SourceMap: true}, {Name: "Foo"}},
+		},
+		{
+			name:     "type names",
+			list:     `[{"name": "IL2ToL1MessagePasser", "typeName": "L2ToL1MessagePasser"}]`,
+			expected: []contractEntry{{Name: "IL2ToL1MessagePasser", TypeName: "L2ToL1MessagePasser"}},
+		},
+		{
+			name: "unexported type name",
+			list: `[{"name": "Foo", "typeName": "foo"}]`,
+			err:  "not an exported Go identifier",
+		},
+		{
+			name: "invalid type name",
+			list: `[{"name": "Foo", "typeName": "Foo-V2"}]`,
+			err:  "not an exported Go identifier",
+		},
+		{
+			name:     "files",
+			list:     `[{"name": "Types", "file": "Types.sol"}, {"name": "Qux", "file": "A/Qux.sol"}]`,
+			expected: []contractEntry{{Name: "Types", File: "Types.sol"}, {Name: "Qux", File: "A/Qux.sol"}},
+		},
+		{
+			name:     "packages",
+			list:     `[{"name": "L1Block", "package": "l2bindings"}]`,
+			expected: []contractEntry{{Name: "L1Block", Package: "l2bindings"}},
+		},
+		{
+			name: "invalid package",
+			list: `[{"name": "L1Block", "package": "l2-bindings"}]`,
+			err:  "not a Go identifier",
+		},
+		{
+			name:     "interface only",
+			list:     `[{"name": "IERC20", "interfaceOnly": true}]`,
+			expected: []contractEntry{{Name: "IERC20", InterfaceOnly: true}},
+		},
+		{
+			name:     "raw",
+			list:     `[{"name": "Vault", "raw": true}]`,
+			expected: []contractEntry{{Name: "Vault", Raw: true}},
+		},
+		{
+			name: "file outside of the artifacts",
+			list: `[{"name": "Foo", "file": "../Foo.sol"}]`,
+			err:  "not a relative path to a .sol file",
+		},
+		{
+			name: "fully-qualified name and file",
+			list: `[{"name": "src/Foo.sol:Foo", "file": "Foo.sol"}]`,
+			err:  "both a fully-qualified name and a file",
+		},
+		{
+			name: "empty",
+			list: `[]`,
+		},
+		{
+			name: "missing name",
+			list: `[{}]`,
+			err:  "has no name",
+		},
+		{
+			name: "unknown option",
+			list: `[{"name": "Foo", "unknown": true}]`,
+			err:  `unknown field "unknown"`,
+		},
+		{
+			name: "not an array",
+			list: `{"name": "Foo"}`,
+			err:  "expected a JSON array",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := decodeContractsList(strings.NewReader(tt.list))
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, entries)
+		})
+	}
+}
+
+func TestBindingsName(t *testing.T) {
+	require.Equal(t, "Foo", contractEntry{Name: "Foo"}.bindingsName())
+	require.Equal(t, "Foo", contractEntry{Name: "src/L1/Foo.sol:Foo"}.bindingsName())
+	require.Equal(t, "Bar", contractEntry{Name: "src/L1/Foo.sol:Foo", TypeName: "Bar"}.bindingsName())
+}
+
+func TestReadContractsLists(t *testing.T) {
+	dir := t.TempDir()
+	writeList := func(name, list string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(list), 0o600))
+		return path
+	}
+	l1 := writeList("l1.json", `["L1Block", {"name": "MIPS", "sourceMap": true}]`)
+	l2 := writeList("l2.json", `["L2OutputOracle", {"name": "MIPS", "sourceMap": true}]`)
+	conflict := writeList("conflict.json", `["MIPS", "L1Block"]`)
+
+	entries, err := readContractsLists(l1+","+l2+",-", strings.NewReader(`["SystemConfig", "L1Block"]`))
+	require.NoError(t, err)
+	require.Equal(t, []contractEntry{{Name: "L1Block"}, {Name: "MIPS", SourceMap: true}, {Name: "L2OutputOracle"}, {Name: "SystemConfig"}}, entries)
+
+	_, err = readContractsLists(l1+","+conflict, nil)
+	require.ErrorContains(t, err, `"MIPS" has different options in `+l1+` and `+conflict)
+
+	_, err = readContractsLists(l1+",-", strings.NewReader(`{}`))
+	require.ErrorContains(t, err, "stdin: error parsing contract list")
+}
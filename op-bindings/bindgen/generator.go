@@ -0,0 +1,1137 @@
+package bindgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/ast"
+	"github.com/ethereum-optimism/optimism/op-bindings/foundry"
+	"github.com/ethereum-optimism/optimism/op-bindings/solc"
+)
+
+// LocalConfig configures the generation of bindings from local forge
+// artifacts.
+type LocalConfig struct {
+	// ForgeArtifacts is the forge artifacts directory, or a zip or gzipped
+	// tar archive of it.
+	ForgeArtifacts string
+	// Contracts is a comma-separated list of paths to JSON lists of contracts
+	// to generate bindings for, where "-" reads a list from stdin. The lists
+	// are merged.
+	Contracts string
+	// SourceMaps is a comma-separated list of contracts to include the
+	// deployed source map of, in addition to the contracts list entries that
+	// set sourceMap.
+	SourceMaps string
+	// OutDir is the directory the metadata files are written to.
+	OutDir string
+	// BindingsOut is the directory the bindings are written to, defaults to a
+	// directory named after Package in the working directory. When it is not
+	// OutDir, the metadata cannot refer to the bindings, so it declares the
+	// creation bytecode of every contract itself.
+	BindingsOut string
+	// Package is the Go package name of the metadata, and of the bindings
+	// unless BindingsPackage is set.
+	Package string
+	// BindingsPackage is the Go package name of the bindings, defaults to
+	// Package. It can only differ from Package when the bindings are written
+	// to a different directory than the metadata.
+	BindingsPackage string
+	// MonorepoBase is the base of the monorepo, used to canonicalize the
+	// storage layouts.
+	MonorepoBase string
+	// Concurrency is the number of contracts generated in parallel, defaults
+	// to GOMAXPROCS.
+	Concurrency int
+	// Force regenerates every contract. Otherwise the contracts whose
+	// generated files were all generated from the same forge artifact, as
+	// recorded by the source hash in their header, are skipped. Only the
+	// artifacts and storage layout files are hashed, so changing any other
+	// option or the template needs a forced run.
+	Force bool
+	// Template is the path to a text/template used to generate the metadata
+	// files, defaults to the built-in template.
+	Template string
+	// Manifest is the path to write a JSON manifest of the generated files
+	// to, if set.
+	Manifest string
+	// CheckStorage fails when a storage layout changes incompatibly with the
+	// previously generated one.
+	CheckStorage bool
+	// StorageAllow is a comma-separated list of contracts allowed to change
+	// their storage layout incompatibly.
+	StorageAllow string
+	// SourceMapMode is how the deployed source maps of the SourceMaps
+	// contracts are emitted, one of the SourceMapMode constants. Defaults to
+	// SourceMapModeEmbed.
+	SourceMapMode string
+	// EmptyABIAllow is a comma-separated list of contracts allowed to have an
+	// empty ABI, such as libraries.
+	EmptyABIAllow string
+	// Strict fails instead of warning when different contracts share the
+	// name of the artifact being used.
+	Strict bool
+	// DryRun logs the files that would be written instead of writing them.
+	DryRun bool
+	// KeepGoing generates every contract it can instead of stopping at the
+	// first failure, and reports the failures of all contracts at the end.
+	KeepGoing bool
+	// Check compares every generated file with the one on disk instead of
+	// writing it, and fails when any of them differ. It implies Force.
+	Check bool
+	// CheckDiff prints a unified diff of every stale file in check mode.
+	CheckDiff bool
+	// Only is a comma-separated list of contract names or glob patterns to
+	// restrict generation to.
+	Only string
+	// VerifyMetadata warns when the metadata hash embedded in the deployed
+	// bytecode does not match the artifact metadata.
+	VerifyMetadata bool
+	// FilenameScheme is how file names are derived from contract names, one
+	// of the FilenameScheme constants. Defaults to FilenameSchemeLower.
+	FilenameScheme string
+	// Combined writes the metadata of every contract to a single file.
+	Combined bool
+	// Summary is the path to write a JSON summary of the run to, if set.
+	Summary string
+	// TempDir is the directory the temporary abigen inputs and extracted
+	// archives are written to, defaults to the system temp directory.
+	TempDir string
+	// CompilerVersionPattern is the regular expression matching the compiler
+	// version in artifact file names, without the .json extension. Defaults
+	// to DefaultCompilerVersionPattern.
+	CompilerVersionPattern string
+	// NatSpec adds the NatSpec documentation of the contracts to the comments
+	// of the bindings.
+	NatSpec bool
+	// MetadataABI registers the ABI of every contract in the metadata. The
+	// ABI is declared as <Name>ABI by the metadata file, or by the bindings
+	// when they are written to the same directory.
+	MetadataABI bool
+	// InterfaceOnly only generates the bindings of every contract, without
+	// their bytecode and without metadata files, in addition to the contracts
+	// list entries that set interfaceOnly.
+	InterfaceOnly bool
+	// AST writes the canonicalized AST of every contract next to its
+	// metadata file.
+	AST bool
+	// CompilerSettings adds the compiler version, optimizer settings and EVM
+	// version of every contract to the metadata.
+	CompilerSettings bool
+	// AllowedCompilerVersions is a comma-separated list of the solc versions,
+	// such as 0.8.15, that the contracts may be compiled with. Every version
+	// is allowed when it is empty.
+	AllowedCompilerVersions string
+	// CompilerVersionWarn warns instead of failing when a contract was
+	// compiled with a version outside of AllowedCompilerVersions.
+	CompilerVersionWarn bool
+	// Prune deletes the generated Go files in the output directories that no
+	// contract of the contracts list is generated to anymore. Only files that
+	// start with the generated code header are deleted.
+	Prune bool
+	// StorageLayoutDir is a directory of storage layouts named <name>.json,
+	// as printed by `forge inspect <name> storageLayout`. They are used for
+	// the contracts whose forge artifact was built without the storageLayout
+	// extra output, which otherwise fail to generate.
+	StorageLayoutDir string
+}
+
+type data struct {
+	// Name is the Go type name of the bindings, which the identifiers of the
+	// metadata are derived from.
+	Name string
+	// Contract is the Solidity contract name the metadata is registered
+	// under.
+	Contract          string
+	StorageLayout     string
+	DeployedBin       string
+	Package           string
+	DeployedSourceMap string
+	// DeployedSourceMapFile is the name of the gzip compressed file the
+	// deployed source map is written to, relative to the metadata file.
+	DeployedSourceMapFile string
+	// Bin is the creation bytecode to register. It is empty for abstract
+	// contracts and interfaces.
+	Bin string
+	// DeclareBin is set when the metadata declares Bin as <Name>Bin, because
+	// the bindings that abigen declares it in are in a different directory.
+	DeclareBin bool
+	// ABI is the escaped ABI JSON to declare as <Name>ABI. It is empty when
+	// the ABI is not registered, or when abigen already declares <Name>ABI
+	// in the same package.
+	ABI string
+	// RegisterABI is set when <Name>ABI is registered in the metadata.
+	RegisterABI bool
+	// Compiler is the compiler configuration the contract was built with. It
+	// is nil unless the compiler settings are added to the metadata.
+	Compiler *artifactMetadata
+	// sourceHash is written to the header of the metadata file, unless it is
+	// zero.
+	sourceHash common.Hash
+}
+
+// combinedData is the input of the metadata template when the metadata of
+// every contract is written to a single file.
+type combinedData struct {
+	Package   string
+	Contracts []data
+	// EmbedsSourceMaps is set when any contract has a DeployedSourceMapFile.
+	EmbedsSourceMaps bool
+}
+
+// generator holds the state shared by every contract processed in a single run.
+type generator struct {
+	LocalConfig
+	logger      log.Logger
+	tmpl        *template.Template
+	tempDir     string
+	bindingsDir string
+	// bindingsPackage is the package of the bindings in bindingsDir.
+	bindingsPackage string
+	sharedDir       bool
+	packagesDir     string
+	packages        map[string]string
+	typeNames       map[string]string
+	artifactsDir    string
+	artifacts       *foundry.ArtifactsDir
+	artifactFiles   map[string]string
+	sourceMapsSet   map[string]struct{}
+	interfaceSet    map[string]struct{}
+	rawSet          map[string]struct{}
+	storageAllow    map[string]struct{}
+	emptyABIAllow   map[string]struct{}
+	// solcVersions holds the AllowedCompilerVersions, it is nil when every
+	// version is allowed.
+	solcVersions  []string
+	canonicalizer *ast.Canonicalizer
+	manifest      manifest
+	combined      combinedMetadata
+	summary       summary
+	stale         staleFiles
+	failures      failures
+}
+
+// GenerateLocal generates the bindings of every contract in the contracts list
+// of cfg from local forge artifacts.
+func GenerateLocal(logger log.Logger, cfg LocalConfig) error {
+	if cfg.Concurrency == 0 {
+		cfg.Concurrency = runtime.GOMAXPROCS(0)
+	}
+	if cfg.FilenameScheme == "" {
+		cfg.FilenameScheme = FilenameSchemeLower
+	}
+	if cfg.SourceMapMode == "" {
+		cfg.SourceMapMode = SourceMapModeEmbed
+	}
+	if cfg.CompilerVersionPattern == "" {
+		cfg.CompilerVersionPattern = DefaultCompilerVersionPattern
+	}
+	if cfg.Check {
+		// Up to date bindings must be regenerated to be compared.
+		cfg.Force = true
+	}
+	if cfg.MonorepoBase == "" {
+		return errors.New("must provide a monorepo base")
+	}
+	if cfg.Concurrency < 1 {
+		return errors.New("concurrency must be at least 1")
+	}
+	if cfg.Check && cfg.DryRun {
+		return errors.New("cannot combine check and dry-run modes")
+	}
+	if cfg.Prune && cfg.Only != "" {
+		return errors.New("cannot combine prune and only, the contracts that are left out would be pruned")
+	}
+	switch cfg.FilenameScheme {
+	case FilenameSchemeLower, FilenameSchemeSnake, FilenameSchemeOriginal:
+	default:
+		return fmt.Errorf("unknown filename scheme %q", cfg.FilenameScheme)
+	}
+	switch cfg.SourceMapMode {
+	case SourceMapModeEmbed, SourceMapModeFile, SourceMapModeNone:
+	default:
+		return fmt.Errorf("unknown source map mode %q", cfg.SourceMapMode)
+	}
+	if cfg.TempDir != "" {
+		if err := checkWritableDir(cfg.TempDir); err != nil {
+			return fmt.Errorf("invalid temp dir: %w", err)
+		}
+	}
+	versionRe, err := regexp.Compile(cfg.CompilerVersionPattern)
+	if err != nil {
+		return fmt.Errorf("invalid compiler version pattern: %w", err)
+	}
+	logger.Info("Using monorepo base", "path", cfg.MonorepoBase)
+
+	entries, err := readContractsLists(cfg.Contracts, os.Stdin)
+	if err != nil {
+		return err
+	}
+	contracts := make([]string, 0, len(entries))
+	contractNames := make(map[string]string, len(entries))
+	typeNames := make(map[string]string, len(entries))
+	artifactFiles := make(map[string]string)
+	sourceMapsSet := make(map[string]struct{})
+	interfaceSet := make(map[string]struct{})
+	rawSet := make(map[string]struct{})
+	packages := make(map[string]string)
+	for _, entry := range entries {
+		contracts = append(contracts, entry.Name)
+		name := entry.contractName()
+		contractNames[entry.Name] = name
+		typeNames[name] = entry.bindingsName()
+		if entry.File != "" {
+			artifactFiles[entry.Name] = entry.File
+		}
+		if entry.SourceMap {
+			sourceMapsSet[name] = struct{}{}
+		}
+		if entry.InterfaceOnly || cfg.InterfaceOnly {
+			interfaceSet[name] = struct{}{}
+		}
+		if entry.Raw {
+			rawSet[name] = struct{}{}
+		}
+		if entry.Package != "" && entry.Package != cfg.Package {
+			packages[name] = entry.Package
+		}
+	}
+
+	sourceMaps := strings.Split(cfg.SourceMaps, ",")
+	for _, k := range sourceMaps {
+		sourceMapsSet[k] = struct{}{}
+	}
+
+	storageAllow := make(map[string]struct{})
+	for _, k := range strings.Split(cfg.StorageAllow, ",") {
+		storageAllow[k] = struct{}{}
+	}
+
+	emptyABIAllow := make(map[string]struct{})
+	for _, k := range strings.Split(cfg.EmptyABIAllow, ",") {
+		emptyABIAllow[k] = struct{}{}
+	}
+
+	var solcVersions []string
+	if cfg.AllowedCompilerVersions != "" {
+		solcVersions = strings.Split(cfg.AllowedCompilerVersions, ",")
+	}
+
+	if len(contracts) == 0 {
+		return errors.New("must define a list of contracts")
+	}
+	if len(packages) > 0 && cfg.Combined {
+		return errors.New("cannot combine the metadata of contracts generated in several packages")
+	}
+
+	if cfg.Only != "" {
+		contracts, err = filterContracts(contracts, strings.Split(cfg.Only, ","))
+		if err != nil {
+			return err
+		}
+		logger.Info("Filtered contracts list", "only", cfg.Only, "contracts", len(contracts))
+	}
+
+	t, err := loadTemplate(cfg.Template, cfg.Combined)
+	if err != nil {
+		return err
+	}
+
+	// Make a temp dir to hold all the inputs for abigen
+	dir, err := os.MkdirTemp(cfg.TempDir, "op-bindings")
+	if err != nil {
+		return err
+	}
+	logger.Info("Using package", "package", cfg.Package)
+
+	defer os.RemoveAll(dir)
+	logger.Debug("Created temp dir", "path", dir)
+
+	bindingsDir := cfg.BindingsOut
+	if bindingsDir == "" {
+		bindingsDir = cfg.Package
+	}
+	if bindingsDir, err = filepath.Abs(bindingsDir); err != nil {
+		return fmt.Errorf("error resolving bindings directory: %w", err)
+	}
+	metadataDir, err := filepath.Abs(cfg.OutDir)
+	if err != nil {
+		return fmt.Errorf("error resolving metadata directory: %w", err)
+	}
+	bindingsPackage := cfg.BindingsPackage
+	if bindingsPackage == "" {
+		bindingsPackage = cfg.Package
+	}
+	if bindingsDir != metadataDir {
+		logger.Info("Writing bindings and metadata to different directories, the metadata declares the creation bytecodes",
+			"bindings", bindingsDir, "metadata", metadataDir, "package", bindingsPackage)
+		if err := checkPackageClause(bindingsDir, bindingsPackage); err != nil {
+			return fmt.Errorf("invalid bindings package: %w", err)
+		}
+	} else if bindingsPackage != cfg.Package {
+		return fmt.Errorf("bindings package %q must be %q, the bindings are written to the metadata directory", bindingsPackage, cfg.Package)
+	}
+
+	artifactsDir := cfg.ForgeArtifacts
+	if isArchive(cfg.ForgeArtifacts) {
+		artifactsDir = filepath.Join(dir, "forge-artifacts")
+		count, err := extractArchive(cfg.ForgeArtifacts, artifactsDir)
+		if err != nil {
+			return err
+		}
+		logger.Info("Extracted forge artifacts", "archive", cfg.ForgeArtifacts, "files", count)
+		if artifactsDir, err = archiveRoot(artifactsDir); err != nil {
+			return err
+		}
+	}
+
+	artifacts, err := foundry.ScanArtifacts(artifactsDir, versionRe)
+	if err != nil {
+		return err
+	}
+
+	g := &generator{
+		LocalConfig:     cfg,
+		logger:          logger,
+		tmpl:            t,
+		tempDir:         dir,
+		bindingsDir:     bindingsDir,
+		bindingsPackage: bindingsPackage,
+		sharedDir:       bindingsDir == metadataDir,
+		packagesDir:     filepath.Dir(metadataDir),
+		packages:        packages,
+		typeNames:       typeNames,
+		artifactsDir:    artifactsDir,
+		artifacts:       artifacts,
+		artifactFiles:   artifactFiles,
+		sourceMapsSet:   sourceMapsSet,
+		interfaceSet:    interfaceSet,
+		rawSet:          rawSet,
+		solcVersions:    solcVersions,
+		storageAllow:    storageAllow,
+		emptyABIAllow:   emptyABIAllow,
+		canonicalizer:   ast.NewCanonicalizer(cfg.MonorepoBase),
+	}
+
+	// Resolve every artifact up front so that a missing contract is reported
+	// before any bindings are written. Contracts are keyed by their contract
+	// name, which their metadata is registered under.
+	artifactPaths := make(map[string]string, len(contracts))
+	ids := make(map[string]string, len(contracts))
+	typeOwners := make(map[string]string, len(contracts))
+	var missing []error
+	for _, id := range contracts {
+		name := contractNames[id]
+		if other, ok := ids[name]; ok {
+			missing = append(missing, fmt.Errorf("%q and %q both register their metadata as %s", other, id, name))
+			continue
+		}
+		ids[name] = id
+		typeName := g.typeName(name)
+		if other, ok := typeOwners[typeName]; ok {
+			missing = append(missing, fmt.Errorf("%q and %q both generate bindings named %s", other, id, typeName))
+			continue
+		}
+		typeOwners[typeName] = id
+
+		artifactPath, err := g.resolveArtifactPath(id)
+		if err != nil {
+			missing = append(missing, err)
+			continue
+		}
+		artifactPaths[name] = artifactPath
+	}
+	if err := errors.Join(missing...); err != nil {
+		return fmt.Errorf("error resolving forge artifacts:\n%w", err)
+	}
+	if err := g.checkOutputCollisions(ids); err != nil {
+		return err
+	}
+	if err := g.createPackageDirs(); err != nil {
+		return err
+	}
+
+	// Each contract reads its own artifact and writes its own output files,
+	// so they can be generated independently. The first failure cancels the
+	// contracts that have not started yet, unless KeepGoing is set.
+	progress := newProgress(logger, len(ids), progressLogInterval)
+	group, ctx := errgroup.WithContext(context.Background())
+	group.SetLimit(cfg.Concurrency)
+	for name := range ids {
+		name := name
+		group.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			err := g.genContract(ctx, name, artifactPaths[name])
+			progress.contractProcessed()
+			if err != nil && cfg.KeepGoing {
+				g.logger.Error("Failed to generate bindings", "contract", name, "err", err)
+				g.failures.add(name, err)
+				g.summary.failed.Add(1)
+				return nil
+			}
+			return err
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return err
+	}
+	if failed := g.failures.err(); failed != nil {
+		// The combined metadata and the manifest would leave out the failed
+		// contracts, so they are only written once everything succeeds.
+		if err := g.logSummary(); err != nil {
+			return err
+		}
+		return failed
+	}
+
+	if cfg.Combined {
+		if err := g.writeCombinedMetadata(); err != nil {
+			return err
+		}
+	}
+	if err := g.writePackageRegistries(); err != nil {
+		return err
+	}
+
+	if cfg.Manifest != "" {
+		data, err := g.manifest.marshal(cfg.Manifest)
+		if err != nil {
+			return err
+		}
+		if err := g.writeOutput(cfg.Manifest, data); err != nil {
+			return err
+		}
+	}
+	if cfg.Prune {
+		names := make([]string, 0, len(ids))
+		for name := range ids {
+			names = append(names, name)
+		}
+		if err := g.prune(names); err != nil {
+			return err
+		}
+	}
+	if err := g.logSummary(); err != nil {
+		return err
+	}
+	if err := g.stale.writeDiffs(os.Stdout); err != nil {
+		return err
+	}
+	return g.stale.err()
+}
+
+// genContract generates the abigen bindings and the metadata file for a
+// single contract.
+func (g *generator) genContract(ctx context.Context, name, artifactPath string) error {
+	g.logger.Info("Generating bindings", "contract", name)
+
+	_, interfaceOnly := g.interfaceSet[name]
+	bindingsFile := g.bindingsFile(name)
+	metadataFile := ""
+	outputs := []string{bindingsFile}
+	if !interfaceOnly {
+		metadataFile = g.metadataFile(name)
+		// The combined metadata file has no source hash of its own, it is
+		// rewritten as a whole on every run.
+		if !g.Combined {
+			outputs = append(outputs, metadataFile)
+		}
+		// The sidecar files are outputs too, so that a contract is not
+		// skipped while any of them is missing.
+		if _, ok := g.sourceMapsSet[name]; ok && g.SourceMapMode == SourceMapModeFile {
+			outputs = append(outputs, filepath.Join(filepath.Dir(metadataFile), g.fileBase(name)+sourceMapFileSuffix))
+		}
+		if g.AST {
+			outputs = append(outputs, filepath.Join(filepath.Dir(metadataFile), g.fileBase(name)+astFileSuffix))
+		}
+	}
+
+	forgeArtifactData, err := foundry.ReadArtifactFile(ctx, artifactPath)
+	if err != nil {
+		return fmt.Errorf("error reading forge artifact of %q: %w", name, err)
+	}
+	g.manifest.add(manifestEntry{
+		Name:         name,
+		Artifact:     g.artifactDisplayPath(artifactPath),
+		ArtifactHash: crypto.Keccak256Hash(forgeArtifactData),
+		Bindings:     bindingsFile,
+		Metadata:     metadataFile,
+	})
+
+	sourceHash, err := g.sourceHash(name, forgeArtifactData)
+	if err != nil {
+		return err
+	}
+	upToDate := false
+	if !g.Force {
+		upToDate, err = isUpToDate(sourceHash, outputs...)
+		if err != nil {
+			return err
+		}
+		// The combined metadata file is rewritten as a whole, so it needs
+		// the metadata of up to date contracts too.
+		if upToDate && (!g.Combined || interfaceOnly) {
+			g.logger.Info("Skipping up to date bindings", "contract", name, "artifact", artifactPath)
+			g.summary.skipped.Add(1)
+			return nil
+		}
+	}
+
+	g.logger.Debug("Using forge-artifact", "contract", name, "path", artifactPath)
+	// The artifact is decoded once, and skipped contracts are not decoded at
+	// all.
+	decoded, err := decodeArtifact(forgeArtifactData)
+	if err != nil {
+		return fmt.Errorf("failed to parse forge artifact of %q: %w", name, err)
+	}
+	artifact, err := decoded.artifact()
+	if err != nil {
+		return fmt.Errorf("failed to parse forge artifact of %q: %w", name, err)
+	}
+	metadata, err := decoded.metadata()
+	if err != nil {
+		return fmt.Errorf("failed to parse forge artifact of %q: %w", name, err)
+	}
+	_, raw := g.rawSet[name]
+	raw = raw || decoded.isRaw()
+	if err := g.checkCompilerVersion(name, metadata.Compiler.Version, raw); err != nil {
+		return err
+	}
+	if err := g.checkABI(name, artifact.Abi); err != nil {
+		return err
+	}
+	if g.VerifyMetadata && !raw {
+		verifyMetadataHash(g.logger, name, decoded.RawMetadata, artifact.DeployedBytecode.Object)
+	}
+
+	// Interface only contracts skip the storage layout canonicalization and
+	// have no metadata.
+	if interfaceOnly {
+		if err := g.genBindings(name, bindingsFile, &artifact, decoded, sourceHash, false); err != nil {
+			return err
+		}
+		g.summary.generated.Add(1)
+		return nil
+	}
+
+	// Raw artifacts have no storage layout to canonicalize, their metadata
+	// registers an empty one.
+	canonicalStorage := &solc.StorageLayout{}
+	if !raw {
+		storage, err := g.storageLayout(name, decoded)
+		if err != nil {
+			return err
+		}
+		canonicalStorage = g.canonicalizer.Canonicalize(&storage)
+		if err := g.checkStorageLayout(name, metadataFile, canonicalStorage); err != nil {
+			return err
+		}
+	}
+	if err := validateStorageLayout(canonicalStorage); err != nil {
+		return fmt.Errorf("invalid storage layout in forge artifact of %q:\n%w", name, err)
+	}
+	ser, err := marshalStorageLayout(canonicalStorage)
+	if err != nil {
+		return err
+	}
+	if g.AST && !raw {
+		if err := g.writeAST(name, filepath.Dir(metadataFile), decoded.Ast); err != nil {
+			return err
+		}
+	}
+	serStr, err := quoteStorageLayout(ser)
+	if err != nil {
+		return fmt.Errorf("invalid storage layout in forge artifact of %q: %w", name, err)
+	}
+
+	if upToDate {
+		g.logger.Info("Skipping up to date bindings", "contract", name, "artifact", artifactPath)
+		g.summary.skipped.Add(1)
+	} else {
+		if err := g.genBindings(name, bindingsFile, &artifact, decoded, sourceHash, true); err != nil {
+			return err
+		}
+		g.summary.generated.Add(1)
+	}
+
+	deployedSourceMap, deployedSourceMapFile := "", ""
+	if _, ok := g.sourceMapsSet[name]; ok && g.SourceMapMode != SourceMapModeNone {
+		g.summary.sourceMaps.Add(1)
+		if g.SourceMapMode == SourceMapModeFile {
+			deployedSourceMapFile = g.fileBase(name) + sourceMapFileSuffix
+			if err := g.writeSourceMapFile(filepath.Join(filepath.Dir(metadataFile), deployedSourceMapFile), artifact.DeployedBytecode.SourceMap); err != nil {
+				return err
+			}
+		} else {
+			deployedSourceMap = artifact.DeployedBytecode.SourceMap
+		}
+	}
+	bin := ""
+	if len(artifact.Bytecode.Object) > 0 {
+		bin = artifact.Bytecode.Object.String()
+	}
+	var compiler *artifactMetadata
+	if g.CompilerSettings {
+		if metadata.Compiler.Version == "" {
+			g.logger.Warn("Cannot add compiler settings, the artifact has no compiler metadata", "contract", name)
+		} else {
+			compiler = &metadata
+		}
+	}
+	abiStr := ""
+	if g.MetadataABI && !g.sharesDir(name) {
+		if abiStr, err = escapeABI(artifact.Abi); err != nil {
+			return fmt.Errorf("error encoding ABI of %q: %w", name, err)
+		}
+	}
+
+	d := data{
+		Name:                  g.typeName(name),
+		Contract:              name,
+		StorageLayout:         serStr,
+		DeployedBin:           artifact.DeployedBytecode.Object.String(),
+		Package:               g.packageOf(name),
+		DeployedSourceMap:     deployedSourceMap,
+		DeployedSourceMapFile: deployedSourceMapFile,
+		Bin:                   bin,
+		DeclareBin:            bin != "" && !g.sharesDir(name),
+		ABI:                   abiStr,
+		RegisterABI:           g.MetadataABI,
+		Compiler:              compiler,
+		sourceHash:            sourceHash,
+	}
+
+	if g.Combined {
+		g.combined.add(d)
+		return nil
+	}
+
+	return g.writeMetadata(metadataFile, d)
+}
+
+// writeAST writes the canonicalized AST of a contract to <name>.ast.json in
+// dir.
+func (g *generator) writeAST(name, dir string, rawAST json.RawMessage) error {
+	if len(rawAST) == 0 || bytes.Equal(rawAST, []byte("null")) {
+		g.logger.Warn("Cannot write AST, the artifact has no AST, is the ast extra output enabled?", "contract", name)
+		return nil
+	}
+	canonicalAST, err := g.canonicalizer.CanonicalizeAST(rawAST)
+	if err != nil {
+		return fmt.Errorf("error canonicalizing AST of %q: %w", name, err)
+	}
+	return g.writeOutput(filepath.Join(dir, g.fileBase(name)+astFileSuffix), append(canonicalAST, '\n'))
+}
+
+// writeMetadata executes the metadata template for a contract and writes the
+// result to metadataFile. The output is gofmt-ed so that it does not depend on
+// the whitespace of the template, and stamped with the source hash of the
+// contract.
+func (g *generator) writeMetadata(metadataFile string, d data) error {
+	var metadata bytes.Buffer
+	if err := g.tmpl.Execute(&metadata, d); err != nil {
+		return fmt.Errorf("error writing template %s: %w", metadataFile, err)
+	}
+	formatted, err := format.Source(metadata.Bytes())
+	if err != nil {
+		return fmt.Errorf("error formatting %s: %w", metadataFile, err)
+	}
+	if d.sourceHash != (common.Hash{}) {
+		formatted = stampSourceHash(formatted, d.sourceHash)
+	}
+	return g.writeOutput(metadataFile, formatted)
+}
+
+// genBindings runs abigen on the ABI and, when withBytecode is set, the
+// bytecode of a contract and writes the result to bindingsFile, stamped with
+// sourceHash. The NatSpec documentation is read from the decoded artifact.
+func (g *generator) genBindings(name, bindingsFile string, artifact *foundry.Artifact, decoded *artifactData, sourceHash common.Hash, withBytecode bool) error {
+	rawAbi := artifact.Abi
+	abiFile := path.Join(g.tempDir, name+".abi")
+	if err := writeFileAtomic(abiFile, rawAbi); err != nil {
+		return fmt.Errorf("error writing file: %w", err)
+	}
+	args := []string{"--abi", abiFile}
+	if withBytecode {
+		rawBytecode := artifact.Bytecode.Object.String()
+		bytecodeFile := path.Join(g.tempDir, name+".bin")
+		if err := writeFileAtomic(bytecodeFile, []byte(rawBytecode)); err != nil {
+			return fmt.Errorf("error writing file: %w", err)
+		}
+		args = append(args, "--bin", bytecodeFile)
+	}
+
+	// abigen writes into the temp dir so that the bindings only reach their
+	// final location through writeOutput.
+	abigenFile := path.Join(g.tempDir, name+".go")
+	args = append(args, "--pkg", g.bindingsPackageOf(name), "--type", g.typeName(name), "--out", abigenFile)
+	cmd := exec.Command("abigen", args...)
+	cmd.Stdout = os.Stdout
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error running abigen for %q: %w", name, err)
+	}
+	bindings, err := os.ReadFile(abigenFile)
+	if err != nil {
+		return fmt.Errorf("error reading bindings of %q: %w", name, err)
+	}
+	if g.NatSpec {
+		bindings = addNatSpec(bindings, g.typeName(name), decoded)
+	}
+	return g.writeOutput(bindingsFile, stampSourceHash(bindings, sourceHash))
+}
+
+// filterContracts returns the entries of the contracts list that match any of
+// the patterns. A pattern is a contract name or a path.Match glob, and matches
+// either the contract name or the full entry. Every pattern must match at
+// least one entry.
+func filterContracts(contracts []string, patterns []string) ([]string, error) {
+	var filtered []string
+	matched := make(map[string]bool, len(patterns))
+	for _, id := range contracts {
+		_, name := foundry.ParseContractID(id)
+		include := false
+		for _, pattern := range patterns {
+			nameMatch, err := path.Match(pattern, name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+			idMatch, _ := path.Match(pattern, id)
+			if nameMatch || idMatch {
+				matched[pattern] = true
+				include = true
+			}
+		}
+		if include {
+			filtered = append(filtered, id)
+		}
+	}
+
+	var unmatched []string
+	for _, pattern := range patterns {
+		if !matched[pattern] {
+			unmatched = append(unmatched, pattern)
+		}
+	}
+	if len(unmatched) > 0 {
+		return nil, fmt.Errorf("not in the contracts list: %s", strings.Join(unmatched, ", "))
+	}
+	return filtered, nil
+}
+
+// loadTemplate parses the metadata template at templatePath, or the built-in
+// template when no path is given.
+func loadTemplate(templatePath string, combined bool) (*template.Template, error) {
+	if templatePath == "" {
+		if combined {
+			return template.New("combined").Parse(combinedTmpl)
+		}
+		return template.New("artifact").Parse(tmpl)
+	}
+	data, err := os.ReadFile(templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading metadata template: %w", err)
+	}
+	t, err := template.New(filepath.Base(templatePath)).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing metadata template %s: %w", templatePath, err)
+	}
+	return t, nil
+}
+
+// checkStorageLayout compares the canonical storage layout of a contract with
+// the one in its previously generated metadata file, when CheckStorage is
+// set, and returns an error describing every incompatible change.
+func (g *generator) checkStorageLayout(name, metadataFile string, layout *solc.StorageLayout) error {
+	if !g.CheckStorage {
+		return nil
+	}
+	prev, err := readCommittedStorageLayout(metadataFile, g.typeName(name))
+	if err != nil {
+		return err
+	}
+	if prev == nil {
+		return nil
+	}
+	changes := storageLayoutChanges(prev, layout)
+	if len(changes) == 0 {
+		return nil
+	}
+	if _, ok := g.storageAllow[name]; ok {
+		g.logger.Warn("Allowing incompatible storage layout changes", "contract", name, "changes", strings.Join(changes, "; "))
+		return nil
+	}
+	return fmt.Errorf("incompatible storage layout changes in %s:\n\t%s", name, strings.Join(changes, "\n\t"))
+}
+
+// checkABI returns an error when the ABI of a contract cannot be parsed, or
+// when it is empty and the contract is not in the empty ABI allowlist, since
+// the bindings of such a contract would be useless.
+func (g *generator) checkABI(name string, rawABI json.RawMessage) error {
+	trimmed := bytes.TrimSpace(rawABI)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		trimmed = []byte("[]")
+	}
+	if _, err := abi.JSON(bytes.NewReader(trimmed)); err != nil {
+		return fmt.Errorf("invalid ABI in forge artifact of %q: %w", name, err)
+	}
+	var entries []json.RawMessage
+	if err := json.Unmarshal(trimmed, &entries); err != nil {
+		return fmt.Errorf("invalid ABI in forge artifact of %q: %w", name, err)
+	}
+	if len(entries) > 0 {
+		return nil
+	}
+	if _, ok := g.emptyABIAllow[name]; ok {
+		return nil
+	}
+	return fmt.Errorf("forge artifact of %q has an empty ABI, allow it explicitly if this is intended", name)
+}
+
+// escapeABI compacts the ABI of a contract and escapes it to be embedded in a
+// Go string literal.
+func escapeABI(rawABI json.RawMessage) (string, error) {
+	var compact bytes.Buffer
+	trimmed := bytes.TrimSpace(rawABI)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		trimmed = []byte("[]")
+	}
+	if err := json.Compact(&compact, trimmed); err != nil {
+		return "", err
+	}
+	quoted := strconv.Quote(compact.String())
+	return quoted[1 : len(quoted)-1], nil
+}
+
+// astFileSuffix is appended to the file base of a contract to name the file
+// its canonicalized AST is written to.
+const astFileSuffix = ".ast.json"
+
+// checkWritableDir returns an error when dir is not a directory that files can
+// be created in.
+func checkWritableDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	f, err := os.CreateTemp(dir, ".op-bindings-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", dir, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// typeName returns the Go type name of the bindings of a contract.
+func (g *generator) typeName(name string) string {
+	if typeName, ok := g.typeNames[name]; ok {
+		return typeName
+	}
+	return name
+}
+
+// bindingsFile returns the path the bindings of a contract are written to.
+func (g *generator) bindingsFile(name string) string {
+	if pkg, ok := g.packages[name]; ok {
+		return filepath.Join(g.packageDir(pkg), g.fileBase(name)+".go")
+	}
+	return filepath.Join(g.bindingsDir, g.fileBase(name)+".go")
+}
+
+// metadataFile returns the path the storage layout and deployed bytecode of a
+// contract are written to.
+func (g *generator) metadataFile(name string) string {
+	if g.Combined {
+		return filepath.Join(g.OutDir, combinedMetadataFile)
+	}
+	if pkg, ok := g.packages[name]; ok {
+		return filepath.Join(g.packageDir(pkg), g.fileBase(name)+"_more.go")
+	}
+	return filepath.Join(g.OutDir, g.fileBase(name)+"_more.go")
+}
+
+// sourceHash returns the hash of the inputs of a contract: its forge artifact
+// and, when there is one, its storage layout file.
+func (g *generator) sourceHash(name string, artifactData []byte) (common.Hash, error) {
+	layoutFile := g.storageLayoutFile(name)
+	if layoutFile == "" {
+		return crypto.Keccak256Hash(artifactData), nil
+	}
+	layoutData, err := os.ReadFile(layoutFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return crypto.Keccak256Hash(artifactData), nil
+	} else if err != nil {
+		return common.Hash{}, fmt.Errorf("error reading storage layout of %q: %w", name, err)
+	}
+	return crypto.Keccak256Hash(artifactData, layoutData), nil
+}
+
+// isUpToDate reports whether every output exists, and every generated Go file
+// among them was generated from inputs with the given source hash. The other
+// outputs are derived from the same inputs as the Go files, so they only need
+// to exist.
+func isUpToDate(sourceHash common.Hash, outputs ...string) (bool, error) {
+	for _, output := range outputs {
+		if filepath.Ext(output) != ".go" {
+			_, err := os.Stat(output)
+			if errors.Is(err, os.ErrNotExist) {
+				return false, nil
+			} else if err != nil {
+				return false, fmt.Errorf("error reading %s: %w", output, err)
+			}
+			continue
+		}
+		stored, err := readSourceHash(output)
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		} else if err != nil {
+			return false, fmt.Errorf("error reading %s: %w", output, err)
+		}
+		if stored != sourceHash {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+var tmpl = `// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package {{.Package}}
+
+import (
+{{- if .DeployedSourceMapFile}}
+	_ "embed"
+{{- end}}
+	"encoding/json"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/solc"
+)
+
+const {{.Name}}StorageLayoutJSON = "{{.StorageLayout}}"
+
+var {{.Name}}StorageLayout = new(solc.StorageLayout)
+
+var {{.Name}}DeployedBin = "{{.DeployedBin}}"
+{{if .DeclareBin}}
+const {{.Name}}Bin = "{{.Bin}}"
+{{end}}{{if .ABI}}
+const {{.Name}}ABI = "{{.ABI}}"
+{{end}}{{if .Compiler}}
+var {{.Name}}CompilerSettings = CompilerSettings{
+	Version:          {{printf "%q" .Compiler.Compiler.Version}},
+	OptimizerEnabled: {{.Compiler.Settings.Optimizer.Enabled}},
+	OptimizerRuns:    {{.Compiler.Settings.Optimizer.Runs}},
+	EVMVersion:       {{printf "%q" .Compiler.Settings.EVMVersion}},
+}
+{{end}}{{if .DeployedSourceMap}}
+var {{.Name}}DeployedSourceMap = "{{.DeployedSourceMap}}"
+{{end}}{{if .DeployedSourceMapFile}}
+//go:embed {{.DeployedSourceMapFile}}
+var {{.Name}}DeployedSourceMapGz []byte
+{{end}}
+func init() {
+	if err := json.Unmarshal([]byte({{.Name}}StorageLayoutJSON), {{.Name}}StorageLayout); err != nil {
+		panic(err)
+	}
+
+	layouts["{{.Contract}}"] = {{.Name}}StorageLayout
+	deployedBytecodes["{{.Contract}}"] = {{.Name}}DeployedBin
+{{- if .Bin}}
+	creationBytecodes["{{.Contract}}"] = {{.Name}}Bin
+{{- end}}
+{{- if .RegisterABI}}
+	abis["{{.Contract}}"] = {{.Name}}ABI
+{{- end}}
+{{- if .Compiler}}
+	compilerSettings["{{.Contract}}"] = {{.Name}}CompilerSettings
+{{- end}}
+{{- if .DeployedSourceMapFile}}
+	deployedSourceMaps["{{.Contract}}"] = {{.Name}}DeployedSourceMapGz
+{{- end}}
+}
+`
+
+var combinedTmpl = `// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package {{.Package}}
+
+import (
+{{- if .EmbedsSourceMaps}}
+	_ "embed"
+{{- end}}
+	"encoding/json"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/solc"
+)
+{{range .Contracts}}
+const {{.Name}}StorageLayoutJSON = "{{.StorageLayout}}"
+
+var {{.Name}}StorageLayout = new(solc.StorageLayout)
+
+var {{.Name}}DeployedBin = "{{.DeployedBin}}"
+{{if .DeclareBin}}
+const {{.Name}}Bin = "{{.Bin}}"
+{{end}}{{if .ABI}}
+const {{.Name}}ABI = "{{.ABI}}"
+{{end}}{{if .Compiler}}
+var {{.Name}}CompilerSettings = CompilerSettings{
+	Version:          {{printf "%q" .Compiler.Compiler.Version}},
+	OptimizerEnabled: {{.Compiler.Settings.Optimizer.Enabled}},
+	OptimizerRuns:    {{.Compiler.Settings.Optimizer.Runs}},
+	EVMVersion:       {{printf "%q" .Compiler.Settings.EVMVersion}},
+}
+{{end}}{{if .DeployedSourceMap}}
+var {{.Name}}DeployedSourceMap = "{{.DeployedSourceMap}}"
+{{end}}{{if .DeployedSourceMapFile}}
+//go:embed {{.DeployedSourceMapFile}}
+var {{.Name}}DeployedSourceMapGz []byte
+{{end}}{{end}}
+func init() {
+{{- range .Contracts}}
+	if err := json.Unmarshal([]byte({{.Name}}StorageLayoutJSON), {{.Name}}StorageLayout); err != nil {
+		panic(err)
+	}
+
+	layouts["{{.Contract}}"] = {{.Name}}StorageLayout
+	deployedBytecodes["{{.Contract}}"] = {{.Name}}DeployedBin
+{{- if .Bin}}
+	creationBytecodes["{{.Contract}}"] = {{.Name}}Bin
+{{- end}}
+{{- if .RegisterABI}}
+	abis["{{.Contract}}"] = {{.Name}}ABI
+{{- end}}
+{{- if .Compiler}}
+	compilerSettings["{{.Contract}}"] = {{.Name}}CompilerSettings
+{{- end}}
+{{- if .DeployedSourceMapFile}}
+	deployedSourceMaps["{{.Contract}}"] = {{.Name}}DeployedSourceMapGz
+{{- end}}
+{{end -}}
+}
+`
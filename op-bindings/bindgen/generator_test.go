@@ -0,0 +1,730 @@
+package bindgen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/ast"
+	"github.com/ethereum-optimism/optimism/op-service/testlog"
+)
+
+func TestFilterContracts(t *testing.T) {
+	contracts := []string{"L1Block", "L1StandardBridge", "L2OutputOracle", "src/L1/Foo.sol:Foo"}
+
+	tests := []struct {
+		patterns []string
+		expected []string
+		err      string
+	}{
+		{patterns: []string{"L1Block"}, expected: []string{"L1Block"}},
+		{patterns: []string{"L1*"}, expected: []string{"L1Block", "L1StandardBridge"}},
+		{patterns: []string{"L2OutputOracle", "L1Block"}, expected: []string{"L1Block", "L2OutputOracle"}},
+		{patterns: []string{"Foo"}, expected: []string{"src/L1/Foo.sol:Foo"}},
+		{patterns: []string{"L1Block", "Missing"}, err: "not in the contracts list: Missing"},
+		{patterns: []string{"L3*"}, err: "not in the contracts list: L3*"},
+		{patterns: []string{"["}, err: `invalid pattern "["`},
+	}
+	for _, tt := range tests {
+		filtered, err := filterContracts(contracts, tt.patterns)
+		if tt.err != "" {
+			require.ErrorContains(t, err, tt.err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, tt.expected, filtered)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"L2OutputOracle":  "l2_output_oracle",
+		"L1Block":         "l1_block",
+		"OptimismPortal":  "optimism_portal",
+		"ERC20":           "erc20",
+		"ERC721Bridge":    "erc721_bridge",
+		"OPStackManager":  "op_stack_manager",
+		"WETH9":           "weth9",
+		"lowercase":       "lowercase",
+		"MIPS":            "mips",
+		"PreimageOracle":  "preimage_oracle",
+		"ProxyAdmin":      "proxy_admin",
+		"AddressManager":  "address_manager",
+		"SystemConfig":    "system_config",
+		"DisputeGameImpl": "dispute_game_impl",
+	}
+	for name, expected := range tests {
+		require.Equal(t, expected, snakeCase(name), name)
+	}
+}
+
+func TestCheckOutputCollisions(t *testing.T) {
+	names := map[string]string{"ERC20": "ERC20", "Erc20": "Erc20", "Foo": "Foo"}
+
+	g := &generator{LocalConfig: LocalConfig{OutDir: t.TempDir(), Package: "bindings", FilenameScheme: FilenameSchemeLower}, bindingsDir: t.TempDir()}
+	require.ErrorContains(t, g.checkOutputCollisions(names), "is generated for ERC20, Erc20")
+
+	g.FilenameScheme = FilenameSchemeOriginal
+	require.NoError(t, g.checkOutputCollisions(names))
+
+	// Interface only contracts have no metadata file to collide with.
+	g.interfaceSet = map[string]struct{}{"Foo": {}}
+	require.NoError(t, g.checkOutputCollisions(map[string]string{"Foo": "Foo", "Foo_more": "Foo_more"}))
+}
+
+func TestGenerateLocalValidatesConfig(t *testing.T) {
+	logger := testlog.Logger(t, log.LvlInfo)
+	require.ErrorContains(t, GenerateLocal(logger, LocalConfig{}), "must provide a monorepo base")
+	require.ErrorContains(t, GenerateLocal(logger, LocalConfig{MonorepoBase: ".", Concurrency: -1}), "concurrency must be at least 1")
+	require.ErrorContains(t, GenerateLocal(logger, LocalConfig{MonorepoBase: ".", FilenameScheme: "kebab"}), `unknown filename scheme "kebab"`)
+	require.ErrorContains(t, GenerateLocal(logger, LocalConfig{MonorepoBase: ".", Check: true, DryRun: true}), "cannot combine check and dry-run modes")
+	require.ErrorContains(t, GenerateLocal(logger, LocalConfig{MonorepoBase: ".", TempDir: filepath.Join(t.TempDir(), "missing")}), "invalid temp dir")
+}
+
+func TestCheckMode(t *testing.T) {
+	dir := t.TempDir()
+	g := &generator{LocalConfig: LocalConfig{Check: true}, logger: testlog.Logger(t, log.LvlInfo)}
+	upToDate := filepath.Join(dir, "uptodate.go")
+	changed := filepath.Join(dir, "changed.go")
+	missing := filepath.Join(dir, "missing.go")
+	require.NoError(t, os.WriteFile(upToDate, []byte("package out\n"), 0o600))
+	require.NoError(t, os.WriteFile(changed, []byte("package out\n"), 0o600))
+
+	require.NoError(t, g.writeOutput(upToDate, []byte("package out\n")))
+	require.NoError(t, g.writeOutput(missing, []byte("package out\n")))
+	require.NoError(t, g.writeOutput(changed, []byte("package out\n\nvar x = 1\n")))
+
+	require.NoFileExists(t, missing)
+	data, err := os.ReadFile(changed)
+	require.NoError(t, err)
+	require.Equal(t, "package out\n", string(data))
+	require.EqualError(t, g.stale.err(), "2 generated files are stale, regenerate the bindings:\n"+changed+"\n"+missing)
+}
+
+func TestDescribeChange(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "foo.go")
+	writeFile(t, existing, "package out\n\nvar x = 1\n")
+	tests := []struct {
+		name     string
+		path     string
+		data     string
+		expected string
+	}{
+		{
+			name:     "create",
+			path:     filepath.Join(dir, "missing.go"),
+			data:     "package out\n",
+			expected: "would create " + filepath.Join(dir, "missing.go") + " (12 bytes)",
+		},
+		{
+			name:     "unchanged",
+			path:     existing,
+			data:     "package out\n\nvar x = 1\n",
+			expected: existing + " is unchanged",
+		},
+		{
+			name:     "update",
+			path:     existing,
+			data:     "package out\n\nvar x = 2\n",
+			expected: "would update " + existing + " (23 bytes -> 23 bytes, first difference at byte 21 on line 3)",
+		},
+		{
+			name:     "append",
+			path:     existing,
+			data:     "package out\n\nvar x = 1\nvar y = 2\n",
+			expected: "would update " + existing + " (23 bytes -> 33 bytes, first difference at byte 23 on line 4)",
+		},
+		{
+			name:     "truncate",
+			path:     existing,
+			data:     "package out\n",
+			expected: "would update " + existing + " (23 bytes -> 12 bytes, first difference at byte 12 on line 2)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change, err := describeChange(tt.path, []byte(tt.data))
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, change)
+		})
+	}
+}
+
+func TestGenerateLocalDryRun(t *testing.T) {
+	cfg := testGenerateConfig(t, `["Foo", {"name": "Bar", "package": "other"}]`)
+	writeForgeArtifact(t, cfg.ForgeArtifacts, "Foo", testStorageLayout("Foo"))
+	writeForgeArtifact(t, cfg.ForgeArtifacts, "Bar", testStorageLayout("Bar"))
+	cfg.Manifest = filepath.Join(cfg.OutDir, "manifest.json")
+	cfg.Summary = filepath.Join(cfg.OutDir, "summary.json")
+	cfg.DryRun = true
+	logger := testlog.Logger(t, log.LvlInfo)
+	logs := testlog.Capture(logger)
+	require.NoError(t, GenerateLocal(logger, cfg))
+
+	entries, err := os.ReadDir(cfg.OutDir)
+	require.NoError(t, err)
+	require.Empty(t, entries, "a dry run writes nothing")
+	require.NoDirExists(t, filepath.Join(filepath.Dir(cfg.OutDir), "other"), "a dry run creates no package directories")
+	change := logs.FindLog(log.LvlInfo, "Dry run")
+	require.NotNil(t, change)
+	require.Contains(t, change.GetContextValue("change"), "would create "+cfg.OutDir)
+
+	// Existing files are left alone too.
+	cfg.DryRun = false
+	require.NoError(t, GenerateLocal(testlog.Logger(t, log.LvlInfo), cfg))
+	writeFile(t, filepath.Join(cfg.ForgeArtifacts, "Foo.sol", "Foo.json"), strings.Replace(readFile(t, filepath.Join(cfg.ForgeArtifacts, "Foo.sol", "Foo.json")), "0x6002", "0x6003", 1))
+	metadata := readFile(t, filepath.Join(cfg.OutDir, "foo_more.go"))
+	cfg.DryRun = true
+	require.NoError(t, GenerateLocal(testlog.Logger(t, log.LvlInfo), cfg))
+	require.Equal(t, metadata, readFile(t, filepath.Join(cfg.OutDir, "foo_more.go")))
+}
+
+func TestCheckWritableDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, checkWritableDir(dir))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries, "the check must not leave files behind")
+
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o600))
+	require.ErrorContains(t, checkWritableDir(file), "is not a directory")
+	require.ErrorIs(t, checkWritableDir(filepath.Join(dir, "missing")), os.ErrNotExist)
+}
+
+func TestLogSummary(t *testing.T) {
+	dir := t.TempDir()
+	g := &generator{LocalConfig: LocalConfig{Summary: filepath.Join(dir, "summary.json")}, logger: testlog.Logger(t, log.LvlInfo)}
+	g.summary.generated.Add(2)
+	g.summary.skipped.Add(1)
+	g.summary.sourceMaps.Add(1)
+	require.NoError(t, g.writeOutput(filepath.Join(dir, "out.go"), []byte("package out\n")))
+	require.NoError(t, g.logSummary())
+
+	data, err := os.ReadFile(g.Summary)
+	require.NoError(t, err)
+	var report summaryReport
+	require.NoError(t, json.Unmarshal(data, &report))
+	require.Equal(t, summaryReport{Contracts: 3, Generated: 2, Skipped: 1, SourceMaps: 1, BytesWritten: 12}, report)
+}
+
+func TestCheckABI(t *testing.T) {
+	g := &generator{emptyABIAllow: map[string]struct{}{"Lib": {}}}
+
+	tests := []struct {
+		name string
+		abi  string
+		err  string
+	}{
+		{name: "Foo", abi: `[{"type":"function","name":"version","inputs":[],"outputs":[{"name":"","type":"string"}],"stateMutability":"view"}]`},
+		{name: "Foo", abi: `[{"type":"constructor","inputs":[]}]`},
+		{name: "Foo", abi: `[]`, err: `forge artifact of "Foo" has an empty ABI`},
+		{name: "Foo", abi: ` `, err: `forge artifact of "Foo" has an empty ABI`},
+		{name: "Foo", abi: `null`, err: `forge artifact of "Foo" has an empty ABI`},
+		{name: "Foo", abi: `{"type":"function"}`, err: `invalid ABI in forge artifact of "Foo"`},
+		{name: "Lib", abi: `[]`},
+	}
+	for _, tt := range tests {
+		err := g.checkABI(tt.name, json.RawMessage(tt.abi))
+		if tt.err != "" {
+			require.ErrorContains(t, err, tt.err, tt.abi)
+			continue
+		}
+		require.NoError(t, err, tt.abi)
+	}
+}
+
+func TestWriteMetadataFormatsOutput(t *testing.T) {
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "metadata.tmpl")
+	require.NoError(t, os.WriteFile(templatePath, []byte("package {{.Package}}\nvar   {{.Name}}DeployedBin   =   \"{{.DeployedBin}}\"\n\n\n"), 0o600))
+	tmpl, err := loadTemplate(templatePath, false)
+	require.NoError(t, err)
+	g := &generator{tmpl: tmpl, logger: testlog.Logger(t, log.LvlInfo)}
+
+	metadataFile := filepath.Join(dir, "foo_more.go")
+	require.NoError(t, g.writeMetadata(metadataFile, data{Name: "Foo", Contract: "Foo", Package: "bindings", DeployedBin: "0x01"}))
+	out, err := os.ReadFile(metadataFile)
+	require.NoError(t, err)
+	require.Equal(t, "package bindings\n\nvar FooDeployedBin = \"0x01\"\n", string(out))
+
+	require.NoError(t, os.WriteFile(templatePath, []byte("package {{.Package}}\nvar {{.Name}} = \n"), 0o600))
+	g.tmpl, err = loadTemplate(templatePath, false)
+	require.NoError(t, err)
+	require.ErrorContains(t, g.writeMetadata(metadataFile, data{Name: "Foo", Contract: "Foo", Package: "bindings"}), "error formatting")
+}
+
+func TestMetadataABITemplate(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, err := loadTemplate("", false)
+	require.NoError(t, err)
+	g := &generator{tmpl: tmpl, logger: testlog.Logger(t, log.LvlInfo)}
+
+	escaped, err := escapeABI([]byte("[\n  {\"type\": \"fallback\", \"stateMutability\": \"payable\"}\n]"))
+	require.NoError(t, err)
+	require.Equal(t, `[{\"type\":\"fallback\",\"stateMutability\":\"payable\"}]`, escaped)
+
+	metadataFile := filepath.Join(dir, "foo_more.go")
+	require.NoError(t, g.writeMetadata(metadataFile, data{Name: "Foo", Contract: "Foo", Package: "bindings", StorageLayout: "{}", DeployedBin: "0x01", ABI: escaped, RegisterABI: true}))
+	out, err := os.ReadFile(metadataFile)
+	require.NoError(t, err)
+	require.Contains(t, string(out), "const FooABI = \""+escaped+"\"\n")
+	require.Contains(t, string(out), `abis["Foo"] = FooABI`)
+
+	// The bindings declare FooABI when they are in the same package.
+	require.NoError(t, g.writeMetadata(metadataFile, data{Name: "Foo", Contract: "Foo", Package: "bindings", StorageLayout: "{}", DeployedBin: "0x01", RegisterABI: true}))
+	out, err = os.ReadFile(metadataFile)
+	require.NoError(t, err)
+	require.NotContains(t, string(out), "const FooABI")
+	require.Contains(t, string(out), `abis["Foo"] = FooABI`)
+}
+
+func TestCompilerSettingsTemplate(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, err := loadTemplate("", false)
+	require.NoError(t, err)
+	g := &generator{tmpl: tmpl, logger: testlog.Logger(t, log.LvlInfo)}
+
+	var compiler artifactMetadata
+	require.NoError(t, json.Unmarshal([]byte(`{"compiler":{"version":"0.8.15+commit.e14f2714"},"settings":{"optimizer":{"enabled":true,"runs":999999},"evmVersion":"london"}}`), &compiler))
+	metadataFile := filepath.Join(dir, "foo_more.go")
+	require.NoError(t, g.writeMetadata(metadataFile, data{Name: "Foo", Contract: "Foo", Package: "bindings", StorageLayout: "{}", DeployedBin: "0x01", Compiler: &compiler}))
+	out, err := os.ReadFile(metadataFile)
+	require.NoError(t, err)
+	require.Contains(t, string(out), `var FooCompilerSettings = CompilerSettings{
+	Version:          "0.8.15+commit.e14f2714",
+	OptimizerEnabled: true,
+	OptimizerRuns:    999999,
+	EVMVersion:       "london",
+}`)
+	require.Contains(t, string(out), `compilerSettings["Foo"] = FooCompilerSettings`)
+}
+
+func TestFailures(t *testing.T) {
+	var f failures
+	require.NoError(t, f.err())
+	f.add("Foo", errors.New("invalid ABI"))
+	f.add("Bar", errors.New("missing artifact"))
+	require.EqualError(t, f.err(), "failed to generate the bindings of 2 contracts:\nBar: missing artifact\nFoo: invalid ABI")
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	existing := "package bindings\n\nconst FooStorageLayoutJSON = \"{\\\"storage\\\":[{\\\"label\\\":\\\"x\\\",\\\"slot\\\":\\\"0\\\"}]}\"\n\nvar FooDeployedBin = \"0x01\"\n"
+	generated := "package bindings\n\nconst FooStorageLayoutJSON = \"{\\\"storage\\\":[{\\\"label\\\":\\\"y\\\",\\\"slot\\\":\\\"0\\\"}]}\"\n\nvar FooDeployedBin = \"0x01\"\n"
+
+	diff, err := unifiedDiff("foo_more.go", []byte(existing), []byte(generated))
+	require.NoError(t, err)
+	require.Equal(t, `--- foo_more.go
++++ foo_more.go (generated)
+@@ -3,7 +3,7 @@
+ const FooStorageLayoutJSON = {
+   "storage": [
+     {
+-      "label": "x",
++      "label": "y",
+       "slot": "0"
+     }
+   ]
+`, diff)
+
+	diff, err = unifiedDiff("foo.srcmap.gz", []byte{0x1f, 0x8b, 0xff}, []byte{0x1f, 0x8b, 0xfe})
+	require.NoError(t, err)
+	require.Equal(t, "Binary files foo.srcmap.gz and foo.srcmap.gz (generated) differ\n", diff)
+}
+
+func TestWriteAST(t *testing.T) {
+	dir := t.TempDir()
+	logger := testlog.Logger(t, log.LvlInfo)
+	logs := testlog.Capture(logger)
+	g := &generator{logger: logger, canonicalizer: ast.NewCanonicalizer("/repo")}
+
+	require.NoError(t, g.writeAST("Foo", dir, json.RawMessage(`{"absolutePath":"/repo/src/Foo.sol","id":7,"nodes":[{"id":6}]}`)))
+	out, err := os.ReadFile(filepath.Join(dir, "foo"+astFileSuffix))
+	require.NoError(t, err)
+	require.Equal(t, `{"absolutePath":"src/Foo.sol","id":1,"nodes":[{"id":2}]}`+"\n", string(out))
+
+	require.NoError(t, g.writeAST("Bar", dir, nil))
+	require.NoFileExists(t, filepath.Join(dir, "bar"+astFileSuffix))
+	require.NotNil(t, logs.FindLog(log.LvlWarn, "Cannot write AST, the artifact has no AST, is the ast extra output enabled?"))
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "foo_more.go")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0o600))
+	require.NoError(t, os.Chmod(path, 0o640))
+
+	require.NoError(t, writeFileAtomic(path, []byte("new")))
+	out, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "new", string(out))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "the temporary file must be renamed over the target")
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o640), info.Mode().Perm(), "the mode of the replaced file must be kept")
+
+	created := filepath.Join(dir, "bar_more.go")
+	require.NoError(t, writeFileAtomic(created, []byte("new")))
+	info, err = os.Stat(created)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o644), info.Mode().Perm())
+
+	require.Error(t, writeFileAtomic(filepath.Join(dir, "missing", "foo_more.go"), []byte("new")))
+}
+
+func TestIsUpToDate(t *testing.T) {
+	dir := t.TempDir()
+	bindings := filepath.Join(dir, "foo.go")
+	sidecar := filepath.Join(dir, "foo.ast.json")
+	hash := common.HexToHash("0x01")
+	writeFile(t, bindings, string(stampSourceHash([]byte(generatedFileHeader+"\n\npackage out\n"), hash)))
+
+	upToDate, err := isUpToDate(hash, bindings)
+	require.NoError(t, err)
+	require.True(t, upToDate)
+
+	upToDate, err = isUpToDate(hash, bindings, sidecar)
+	require.NoError(t, err)
+	require.False(t, upToDate, "a missing sidecar file is not up to date")
+	writeFile(t, sidecar, "{}")
+	upToDate, err = isUpToDate(hash, bindings, sidecar)
+	require.NoError(t, err)
+	require.True(t, upToDate)
+
+	upToDate, err = isUpToDate(common.HexToHash("0x02"), bindings)
+	require.NoError(t, err)
+	require.False(t, upToDate, "the artifact changed")
+
+	writeFile(t, bindings, generatedFileHeader+"\n\npackage out\n")
+	upToDate, err = isUpToDate(hash, bindings)
+	require.NoError(t, err)
+	require.False(t, upToDate, "bindings without a source hash are not up to date")
+
+	upToDate, err = isUpToDate(hash, filepath.Join(dir, "missing.go"))
+	require.NoError(t, err)
+	require.False(t, upToDate)
+}
+
+func TestStampSourceHash(t *testing.T) {
+	hash := common.HexToHash("0x01")
+	for _, tt := range []struct {
+		src, stamped string
+	}{
+		{
+			src:     "// Code generated - DO NOT EDIT.\n// This file is a generated binding and any manual changes will be lost.\n\npackage bindings\n",
+			stamped: "// Code generated - DO NOT EDIT.\n// This file is a generated binding and any manual changes will be lost.\n// Source hash: " + hash.Hex() + "\n\npackage bindings\n",
+		},
+		{
+			src:     "package bindings\n",
+			stamped: "// Source hash: " + hash.Hex() + "\n\npackage bindings\n",
+		},
+	} {
+		stamped := stampSourceHash([]byte(tt.src), hash)
+		require.Equal(t, tt.stamped, string(stamped))
+
+		path := filepath.Join(t.TempDir(), "foo.go")
+		writeFile(t, path, string(stamped))
+		read, err := readSourceHash(path)
+		require.NoError(t, err)
+		require.Equal(t, hash, read)
+	}
+
+	// A source hash after the header is not part of it.
+	path := filepath.Join(t.TempDir(), "foo.go")
+	writeFile(t, path, "package bindings\n\n// Source hash: "+hash.Hex()+"\n")
+	read, err := readSourceHash(path)
+	require.NoError(t, err)
+	require.Equal(t, common.Hash{}, read)
+}
+
+// fakeAbigen stands in for abigen in the tests that run GenerateLocal. It
+// writes a stub binding that declares <type>Bin when it is given a bytecode.
+const fakeAbigen = `#!/bin/sh
+while [ $# -gt 0 ]; do
+	case "$1" in
+	--pkg) pkg=$2; shift ;;
+	--type) typ=$2; shift ;;
+	--bin) bin=$2; shift ;;
+	--out) out=$2; shift ;;
+	esac
+	shift
+done
+printf '// Code generated - DO NOT EDIT.\n\npackage %s\n\n// %s is a stub binding.\ntype %s struct{}\n' "$pkg" "$typ" "$typ" > "$out"
+if [ -n "$bin" ]; then
+	printf '\nvar %sBin = "%s"\n' "$typ" "$(cat "$bin")" >> "$out"
+fi
+`
+
+// installFakeAbigen puts fakeAbigen first in the PATH of the test.
+func installFakeAbigen(t *testing.T) {
+	t.Helper()
+	binDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "abigen"), []byte(fakeAbigen), 0o700))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// testStorageLayout returns the storage layout of a contract with a single
+// uint256 variable.
+func testStorageLayout(name string) string {
+	return fmt.Sprintf(`{
+		"storage": [{"astId":1,"contract":"src/%[1]s.sol:%[1]s","label":"x","offset":0,"slot":"0","type":"t_uint256"}],
+		"types": {"t_uint256": {"encoding":"inplace","label":"uint256","numberOfBytes":"32"}}
+	}`, name)
+}
+
+// writeForgeArtifact writes the forge artifact of a contract to
+// <name>.sol/<name>.json in dir.
+func writeForgeArtifact(t *testing.T, dir, name, storageLayout string) {
+	t.Helper()
+	artifact := fmt.Sprintf(`{
+		"abi": [{"type":"function","name":"x","inputs":[],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"}],
+		"bytecode": {"object": "0x6001"},
+		"deployedBytecode": {"object": "0x6002", "sourceMap": "1:2:0:-:0"},
+		"storageLayout": %[2]s,
+		"metadata": {"compiler": {"version": "0.8.15"}, "settings": {"compilationTarget": {"src/%[1]s.sol": %[1]q}}}
+	}`, name, storageLayout)
+	writeFile(t, filepath.Join(dir, name+".sol", name+".json"), artifact)
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+// testGenerateConfig returns the configuration of a run that generates the
+// contracts in the contracts list from the artifacts written to the returned
+// config's ForgeArtifacts directory.
+func testGenerateConfig(t *testing.T, contracts string) LocalConfig {
+	t.Helper()
+	installFakeAbigen(t)
+	dir := t.TempDir()
+	contractsFile := filepath.Join(dir, "contracts.json")
+	writeFile(t, contractsFile, contracts)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "bindings"), 0o755))
+	return LocalConfig{
+		ForgeArtifacts: filepath.Join(dir, "forge-artifacts"),
+		Contracts:      contractsFile,
+		OutDir:         filepath.Join(dir, "bindings"),
+		BindingsOut:    filepath.Join(dir, "bindings"),
+		Package:        "bindings",
+		MonorepoBase:   dir,
+		TempDir:        dir,
+	}
+}
+
+func TestGenerateLocalSeparateBindingsDir(t *testing.T) {
+	cfg := testGenerateConfig(t, `["Foo"]`)
+	writeForgeArtifact(t, cfg.ForgeArtifacts, "Foo", testStorageLayout("Foo"))
+	cfg.BindingsOut = filepath.Join(filepath.Dir(cfg.OutDir), "abi")
+	cfg.BindingsPackage = "abi"
+	require.NoError(t, os.Mkdir(cfg.BindingsOut, 0o755))
+	logger := testlog.Logger(t, log.LvlInfo)
+	require.NoError(t, GenerateLocal(logger, cfg))
+
+	bindings := readFile(t, filepath.Join(cfg.BindingsOut, "foo.go"))
+	require.Contains(t, bindings, "package abi\n")
+	require.Contains(t, bindings, `var FooBin = "0x6001"`)
+	metadata := readFile(t, filepath.Join(cfg.OutDir, "foo_more.go"))
+	require.Contains(t, metadata, "package bindings\n")
+	require.Contains(t, metadata, `const FooBin = "0x6001"`, "the metadata declares the bytecode the bindings declare in another package")
+	require.Contains(t, metadata, `creationBytecodes["Foo"] = FooBin`)
+
+	// The bindings of another package cannot be added to the directory.
+	cfg.BindingsPackage = "other"
+	require.ErrorContains(t, GenerateLocal(logger, cfg), filepath.Join(cfg.BindingsOut, "foo.go")+" declares package abi, not other")
+
+	cfg.BindingsOut = cfg.OutDir
+	require.ErrorContains(t, GenerateLocal(logger, cfg), `bindings package "other" must be "bindings"`)
+	cfg.BindingsPackage = ""
+	require.NoError(t, GenerateLocal(logger, cfg))
+	metadata = readFile(t, filepath.Join(cfg.OutDir, "foo_more.go"))
+	require.NotContains(t, metadata, "const FooBin", "the bindings declare the bytecode in the same package")
+	require.Contains(t, metadata, `creationBytecodes["Foo"] = FooBin`)
+}
+
+func TestGenerateLocalTypeName(t *testing.T) {
+	cfg := testGenerateConfig(t, `[{"name": "IFoo", "typeName": "Foo"}, "Bar"]`)
+	writeForgeArtifact(t, cfg.ForgeArtifacts, "IFoo", "null")
+	writeForgeArtifact(t, cfg.ForgeArtifacts, "Bar", testStorageLayout("Bar"))
+	cfg.StorageLayoutDir = t.TempDir()
+	writeFile(t, filepath.Join(cfg.StorageLayoutDir, "IFoo.json"), testStorageLayout("IFoo"))
+	cfg.SourceMaps = "IFoo"
+	require.NoError(t, GenerateLocal(testlog.Logger(t, log.LvlInfo), cfg))
+
+	// The type name names the Go identifiers and the files.
+	require.Contains(t, readFile(t, filepath.Join(cfg.OutDir, "foo.go")), "type Foo struct{}")
+	metadata := readFile(t, filepath.Join(cfg.OutDir, "foo_more.go"))
+	require.NoFileExists(t, filepath.Join(cfg.OutDir, "ifoo.go"))
+	// The contract name selects the source map and the storage layout file,
+	// and registers the metadata.
+	require.Contains(t, metadata, `var FooDeployedSourceMap = "1:2:0:-:0"`)
+	require.Contains(t, metadata, `src/IFoo.sol:IFoo`)
+	require.Contains(t, metadata, `layouts["IFoo"] = FooStorageLayout`)
+	require.Contains(t, metadata, `deployedBytecodes["IFoo"] = FooDeployedBin`)
+	require.Contains(t, metadata, `creationBytecodes["IFoo"] = FooBin`)
+	require.NotContains(t, readFile(t, filepath.Join(cfg.OutDir, "bar_more.go")), "DeployedSourceMap")
+
+	cfg.Contracts = filepath.Join(t.TempDir(), "contracts.json")
+	writeFile(t, cfg.Contracts, `[{"name": "IFoo", "typeName": "Bar"}, "Bar"]`)
+	require.ErrorContains(t, GenerateLocal(testlog.Logger(t, log.LvlInfo), cfg), `"IFoo" and "Bar" both generate bindings named Bar`)
+	writeFile(t, cfg.Contracts, `[{"name": "src/A.sol:Foo", "typeName": "FooA"}, {"name": "src/B.sol:Foo", "typeName": "FooB"}]`)
+	require.ErrorContains(t, GenerateLocal(testlog.Logger(t, log.LvlInfo), cfg), `"src/A.sol:Foo" and "src/B.sol:Foo" both register their metadata as Foo`)
+}
+
+// readTestSummary reads the summary of a run written to path.
+func readTestSummary(t *testing.T, path string) summaryReport {
+	t.Helper()
+	var r summaryReport
+	require.NoError(t, json.Unmarshal([]byte(readFile(t, path)), &r))
+	return r
+}
+
+func TestGenerateLocalSkipsUpToDate(t *testing.T) {
+	cfg := testGenerateConfig(t, `["Foo", {"name": "Bar", "package": "other"}]`)
+	writeForgeArtifact(t, cfg.ForgeArtifacts, "Foo", testStorageLayout("Foo"))
+	writeForgeArtifact(t, cfg.ForgeArtifacts, "Bar", testStorageLayout("Bar"))
+	cfg.Summary = filepath.Join(t.TempDir(), "summary.json")
+	logger := testlog.Logger(t, log.LvlInfo)
+	require.NoError(t, GenerateLocal(logger, cfg))
+	require.Equal(t, int64(2), readTestSummary(t, cfg.Summary).Generated)
+
+	fooBindings := filepath.Join(cfg.OutDir, "foo.go")
+	artifactData, err := os.ReadFile(filepath.Join(cfg.ForgeArtifacts, "Foo.sol", "Foo.json"))
+	require.NoError(t, err)
+	sourceHash := crypto.Keccak256Hash(artifactData)
+	require.Contains(t, readFile(t, fooBindings), "\n// Source hash: "+sourceHash.Hex()+"\n")
+	require.Contains(t, readFile(t, filepath.Join(cfg.OutDir, "foo_more.go")), "\n// Source hash: "+sourceHash.Hex()+"\n")
+
+	// Timestamps do not matter, only the contents of the artifacts.
+	past := time.Now().Add(-time.Hour)
+	registry := filepath.Join(filepath.Dir(cfg.OutDir), "other", packageRegistryFile)
+	for _, path := range []string{fooBindings, registry} {
+		require.NoError(t, os.Chtimes(path, past, past))
+	}
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(cfg.ForgeArtifacts, "Foo.sol", "Foo.json"), future, future))
+	require.NoError(t, GenerateLocal(logger, cfg))
+	summary := readTestSummary(t, cfg.Summary)
+	require.Equal(t, int64(0), summary.Generated)
+	require.Equal(t, int64(2), summary.Skipped)
+
+	// A changed artifact is regenerated, while the registry of the other
+	// package is left alone.
+	writeForgeArtifact(t, cfg.ForgeArtifacts, "Foo", strings.Replace(testStorageLayout("Foo"), `"label":"x"`, `"label":"y"`, 1))
+	require.NoError(t, GenerateLocal(logger, cfg))
+	summary = readTestSummary(t, cfg.Summary)
+	require.Equal(t, int64(1), summary.Generated)
+	require.Equal(t, int64(1), summary.Skipped)
+	require.Contains(t, readFile(t, filepath.Join(cfg.OutDir, "foo_more.go")), `\"label\":\"y\"`)
+
+	cfg.Force = true
+	require.NoError(t, GenerateLocal(logger, cfg))
+	require.Equal(t, int64(2), readTestSummary(t, cfg.Summary).Generated)
+	info, err := os.Stat(registry)
+	require.NoError(t, err)
+	require.True(t, info.ModTime().Equal(past), "an unchanged registry is not rewritten")
+}
+
+// countLogs returns the number of records logged with msg.
+func countLogs(logs *testlog.CapturingHandler, msg string) int {
+	count := 0
+	for _, record := range logs.Logs {
+		if record.Msg == msg {
+			count++
+		}
+	}
+	return count
+}
+
+func TestGenerateLocalConcurrency(t *testing.T) {
+	names := []string{"A", "B", "C", "D", "E", "F", "G", "H"}
+	contracts, err := json.Marshal(names)
+	require.NoError(t, err)
+	cfg := testGenerateConfig(t, string(contracts))
+	for _, name := range names {
+		writeForgeArtifact(t, cfg.ForgeArtifacts, name, testStorageLayout(name))
+	}
+	cfg.Concurrency = 4
+	require.NoError(t, GenerateLocal(testlog.Logger(t, log.LvlInfo), cfg))
+	for _, name := range names {
+		require.FileExists(t, filepath.Join(cfg.OutDir, strings.ToLower(name)+".go"))
+		require.Contains(t, readFile(t, filepath.Join(cfg.OutDir, strings.ToLower(name)+"_more.go")), `layouts["`+name+`"]`)
+	}
+
+	// The first failure cancels the contracts that have not started yet.
+	emptyABI := `{"abi":[],"bytecode":"0x6001","deployedBytecode":"0x6002"}`
+	for _, name := range names {
+		writeFile(t, filepath.Join(cfg.ForgeArtifacts, name+".sol", name+".json"), emptyABI)
+	}
+	cfg.Concurrency = 1
+	cfg.Force = true
+	logger := testlog.Logger(t, log.LvlInfo)
+	logs := testlog.Capture(logger)
+	require.ErrorContains(t, GenerateLocal(logger, cfg), "has an empty ABI, allow it explicitly if this is intended")
+	require.Equal(t, 1, countLogs(logs, "Generating bindings"))
+}
+
+func TestGenerateLocalMissingArtifacts(t *testing.T) {
+	cfg := testGenerateConfig(t, `["Foo", "Missing", "src/Foo.sol:Other"]`)
+	writeForgeArtifact(t, cfg.ForgeArtifacts, "Foo", testStorageLayout("Foo"))
+	err := GenerateLocal(testlog.Logger(t, log.LvlInfo), cfg)
+	require.EqualError(t, err, `error resolving forge artifacts:
+cannot find forge-artifact of "Missing"
+cannot find forge-artifact of "src/Foo.sol:Other"`)
+
+	entries, err := os.ReadDir(cfg.OutDir)
+	require.NoError(t, err)
+	require.Empty(t, entries, "nothing is generated while any artifact is missing")
+}
+
+func TestGenerateLocalTemplate(t *testing.T) {
+	cfg := testGenerateConfig(t, `["Foo"]`)
+	writeForgeArtifact(t, cfg.ForgeArtifacts, "Foo", testStorageLayout("Foo"))
+	cfg.Template = filepath.Join(t.TempDir(), "metadata.tmpl")
+	writeFile(t, cfg.Template, `// SPDX-License-Identifier: MIT
+//go:build !minimal
+
+package {{.Package}}
+
+const {{.Name}}StorageLayoutJSON = "{{.StorageLayout}}"
+
+var {{.Name}}DeployedBin = "{{.DeployedBin}}"
+
+var {{.Name}}Contract = "{{.Contract}}"
+`)
+	require.NoError(t, GenerateLocal(testlog.Logger(t, log.LvlInfo), cfg))
+
+	metadata := readFile(t, filepath.Join(cfg.OutDir, "foo_more.go"))
+	require.True(t, strings.HasPrefix(metadata, "// SPDX-License-Identifier: MIT\n//go:build !minimal\n"), metadata)
+	require.Contains(t, metadata, "\npackage bindings\n")
+	require.Contains(t, metadata, `var FooDeployedBin = "0x6002"`)
+	require.Contains(t, metadata, `var FooContract = "Foo"`)
+	require.Contains(t, metadata, `const FooStorageLayoutJSON = "{\"storage\":[{\"astId\":`)
+
+	cfg.Template = filepath.Join(t.TempDir(), "missing.tmpl")
+	require.ErrorContains(t, GenerateLocal(testlog.Logger(t, log.LvlInfo), cfg), "error reading metadata template")
+	writeFile(t, cfg.Template, "package {{.Package")
+	require.ErrorContains(t, GenerateLocal(testlog.Logger(t, log.LvlInfo), cfg), "error parsing metadata template "+cfg.Template)
+}
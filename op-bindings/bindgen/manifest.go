@@ -0,0 +1,80 @@
+package bindgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// manifestEntry records which forge artifact the bindings of a contract were
+// generated from.
+type manifestEntry struct {
+	Name         string      `json:"name"`
+	Artifact     string      `json:"artifact"`
+	ArtifactHash common.Hash `json:"artifactHash"`
+	Bindings     string      `json:"bindings"`
+	Metadata     string      `json:"metadata,omitempty"`
+}
+
+// manifest collects the entries of every contract processed in a run. It is
+// safe for concurrent use.
+type manifest struct {
+	mu      sync.Mutex
+	entries []manifestEntry
+}
+
+func (m *manifest) add(entry manifestEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, entry)
+}
+
+// marshal encodes the manifest that will be written to path, sorted by
+// contract name. Paths are made relative to the directory holding the manifest
+// so that it does not depend on where the repository is checked out.
+func (m *manifest) marshal(path string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	base := filepath.Dir(path)
+	entries := make([]manifestEntry, len(m.entries))
+	for i, entry := range m.entries {
+		entry.Artifact = relativePath(base, entry.Artifact)
+		entry.Bindings = relativePath(base, entry.Bindings)
+		if entry.Metadata != "" {
+			entry.Metadata = relativePath(base, entry.Metadata)
+		}
+		entries[i] = entry
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling manifest: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// relativePath returns target relative to base, or target itself when no
+// relative path exists.
+func relativePath(base, target string) string {
+	absBase, err := filepath.Abs(base)
+	if err != nil {
+		return target
+	}
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return target
+	}
+	rel, err := filepath.Rel(absBase, absTarget)
+	if err != nil {
+		return target
+	}
+	return filepath.ToSlash(rel)
+}
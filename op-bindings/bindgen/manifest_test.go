@@ -0,0 +1,137 @@
+package bindgen
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimism/op-service/testlog"
+)
+
+func TestManifestMarshal(t *testing.T) {
+	dir := t.TempDir()
+	hash := common.HexToHash("0x01")
+	tests := []struct {
+		name     string
+		manifest string
+		entries  []manifestEntry
+		expected []manifestEntry
+	}{
+		{
+			name:     "paths relative to the manifest dir",
+			manifest: filepath.Join(dir, "bindings", "manifest.json"),
+			entries: []manifestEntry{{
+				Name:         "Foo",
+				Artifact:     filepath.Join(dir, "forge-artifacts", "Foo.sol", "Foo.json"),
+				ArtifactHash: hash,
+				Bindings:     filepath.Join(dir, "bindings", "foo.go"),
+				Metadata:     filepath.Join(dir, "bindings", "more", "foo_more.go"),
+			}},
+			expected: []manifestEntry{{
+				Name:         "Foo",
+				Artifact:     "../forge-artifacts/Foo.sol/Foo.json",
+				ArtifactHash: hash,
+				Bindings:     "foo.go",
+				Metadata:     "more/foo_more.go",
+			}},
+		},
+		{
+			name:     "interface only contracts have no metadata",
+			manifest: filepath.Join(dir, "manifest.json"),
+			entries: []manifestEntry{{
+				Name:     "IFoo",
+				Artifact: filepath.Join(dir, "IFoo.json"),
+				Bindings: filepath.Join(dir, "bindings", "ifoo.go"),
+			}},
+			expected: []manifestEntry{{
+				Name:     "IFoo",
+				Artifact: "IFoo.json",
+				Bindings: "bindings/ifoo.go",
+			}},
+		},
+		{
+			name:     "sorted by contract name",
+			manifest: filepath.Join(dir, "manifest.json"),
+			entries: []manifestEntry{
+				{Name: "Foo", Artifact: filepath.Join(dir, "Foo.json"), Bindings: filepath.Join(dir, "foo.go")},
+				{Name: "Bar", Artifact: filepath.Join(dir, "Bar.json"), Bindings: filepath.Join(dir, "bar.go")},
+				{Name: "Baz", Artifact: filepath.Join(dir, "Baz.json"), Bindings: filepath.Join(dir, "baz.go")},
+			},
+			expected: []manifestEntry{
+				{Name: "Bar", Artifact: "Bar.json", Bindings: "bar.go"},
+				{Name: "Baz", Artifact: "Baz.json", Bindings: "baz.go"},
+				{Name: "Foo", Artifact: "Foo.json", Bindings: "foo.go"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m manifest
+			for _, entry := range tt.entries {
+				m.add(entry)
+			}
+			data, err := m.marshal(tt.manifest)
+			require.NoError(t, err)
+			var entries []manifestEntry
+			require.NoError(t, json.Unmarshal(data, &entries))
+			require.Equal(t, tt.expected, entries)
+
+			// The output does not depend on the order contracts finish in.
+			var reversed manifest
+			for i := len(tt.entries) - 1; i >= 0; i-- {
+				reversed.add(tt.entries[i])
+			}
+			reversedData, err := reversed.marshal(tt.manifest)
+			require.NoError(t, err)
+			require.Equal(t, data, reversedData)
+		})
+	}
+}
+
+func TestManifestArtifactHash(t *testing.T) {
+	cfg := testGenerateConfig(t, `["Foo"]`)
+	artifactPath := filepath.Join(cfg.ForgeArtifacts, "Foo.sol", "Foo.json")
+	writeFile(t, artifactPath, `{"abi":[],"bytecode":"0x6001","deployedBytecode":"0x6002"}`)
+	cfg.EmptyABIAllow = "Foo"
+	cfg.Manifest = filepath.Join(cfg.OutDir, "manifest.json")
+	require.NoError(t, GenerateLocal(testlog.Logger(t, log.LvlInfo), cfg))
+
+	var entries []manifestEntry
+	require.NoError(t, json.Unmarshal([]byte(readFile(t, cfg.Manifest)), &entries))
+	require.Len(t, entries, 1)
+	// The hash is the keccak256 hash of the artifact file as it is on disk.
+	require.Equal(t, common.HexToHash("0x4d96b6cddb4e86f28e3032dc4d1e1837e61b50cad3ed0c70ea06c775ee75ede3"), entries[0].ArtifactHash)
+	require.Equal(t, crypto.Keccak256Hash([]byte(readFile(t, artifactPath))), entries[0].ArtifactHash)
+	require.Equal(t, manifestEntry{
+		Name:         "Foo",
+		Artifact:     "../forge-artifacts/Foo.sol/Foo.json",
+		ArtifactHash: entries[0].ArtifactHash,
+		Bindings:     "foo.go",
+		Metadata:     "foo_more.go",
+	}, entries[0])
+}
+
+func TestManifestCheckMode(t *testing.T) {
+	cfg := testGenerateConfig(t, `["Foo"]`)
+	writeForgeArtifact(t, cfg.ForgeArtifacts, "Foo", testStorageLayout("Foo"))
+	cfg.Manifest = filepath.Join(cfg.OutDir, "manifest.json")
+	require.NoError(t, GenerateLocal(testlog.Logger(t, log.LvlInfo), cfg))
+
+	cfg.Check = true
+	require.NoError(t, GenerateLocal(testlog.Logger(t, log.LvlInfo), cfg))
+
+	writeFile(t, cfg.Manifest, "[]\n")
+	require.EqualError(t, GenerateLocal(testlog.Logger(t, log.LvlInfo), cfg),
+		"1 generated files are stale, regenerate the bindings:\n"+cfg.Manifest)
+	require.Equal(t, "[]\n", readFile(t, cfg.Manifest), "check mode does not write the manifest")
+
+	require.NoError(t, os.Remove(cfg.Manifest))
+	require.ErrorContains(t, GenerateLocal(testlog.Logger(t, log.LvlInfo), cfg), cfg.Manifest)
+	require.NoFileExists(t, cfg.Manifest)
+}
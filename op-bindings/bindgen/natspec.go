@@ -0,0 +1,105 @@
+package bindgen
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// userDoc is the user documentation that solc extracts from the NatSpec
+// comments of a contract. Methods and events are keyed by their signature.
+type userDoc struct {
+	Notice  string                  `json:"notice"`
+	Methods map[string]userDocEntry `json:"methods"`
+	Events  map[string]userDocEntry `json:"events"`
+}
+
+type userDocEntry struct {
+	Notice string `json:"notice"`
+}
+
+// devDoc is the developer documentation that solc extracts from the NatSpec
+// comments of a contract. Methods and events are keyed by their signature.
+type devDoc struct {
+	Title   string                 `json:"title"`
+	Details string                 `json:"details"`
+	Methods map[string]devDocEntry `json:"methods"`
+	Events  map[string]devDocEntry `json:"events"`
+}
+
+type devDocEntry struct {
+	Details string `json:"details"`
+}
+
+// abigenBindingRe matches the first line of the comment abigen writes above
+// every method and event binding, which ends with the method selector or the
+// event topic.
+var abigenBindingRe = regexp.MustCompile(`binding the contract (method|event) (0x[0-9a-f]+)\.$`)
+
+// addNatSpec appends the NatSpec notice and details of the contract, its
+// methods and its events to the comments of the matching abigen bindings.
+// Methods are matched by selector and events by topic, so overloads get the
+// documentation of the right signature.
+func addNatSpec(bindings []byte, name string, artifact *artifactData) []byte {
+	docs := make(map[string][]string)
+	for sig, entry := range artifact.UserDoc.Methods {
+		selector := hexutil.Encode(crypto.Keccak256([]byte(sig))[:4])
+		docs[selector] = append(docs[selector], entry.Notice)
+	}
+	for sig, entry := range artifact.DevDoc.Methods {
+		selector := hexutil.Encode(crypto.Keccak256([]byte(sig))[:4])
+		docs[selector] = append(docs[selector], entry.Details)
+	}
+	for sig, entry := range artifact.UserDoc.Events {
+		topic := crypto.Keccak256Hash([]byte(sig)).Hex()
+		docs[topic] = append(docs[topic], entry.Notice)
+	}
+	for sig, entry := range artifact.DevDoc.Events {
+		topic := crypto.Keccak256Hash([]byte(sig)).Hex()
+		docs[topic] = append(docs[topic], entry.Details)
+	}
+	contractComment := "// " + name + " is an auto generated Go binding around an Ethereum contract."
+	contractDocs := []string{artifact.DevDoc.Title, artifact.UserDoc.Notice, artifact.DevDoc.Details}
+
+	var out bytes.Buffer
+	var pending []string
+	for _, line := range strings.SplitAfter(string(bindings), "\n") {
+		trimmed := strings.TrimSuffix(line, "\n")
+		if pending != nil && !strings.HasPrefix(trimmed, "//") {
+			out.WriteString(docComment(pending))
+			pending = nil
+		}
+		if trimmed == contractComment {
+			pending = contractDocs
+		} else if m := abigenBindingRe.FindStringSubmatch(trimmed); m != nil {
+			pending = docs[m[2]]
+		}
+		out.WriteString(line)
+	}
+	return out.Bytes()
+}
+
+// docComment formats NatSpec texts as paragraphs that continue a Go comment.
+// Empty texts are skipped.
+func docComment(texts []string) string {
+	var b strings.Builder
+	for _, text := range texts {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		b.WriteString("//\n")
+		for _, line := range strings.Split(text, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				b.WriteString("//\n")
+				continue
+			}
+			b.WriteString("// " + line + "\n")
+		}
+	}
+	return b.String()
+}
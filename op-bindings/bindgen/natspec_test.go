@@ -0,0 +1,84 @@
+package bindgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddNatSpec(t *testing.T) {
+	bindings := `// Oracle is an auto generated Go binding around an Ethereum contract.
+type Oracle struct {
+}
+
+// CHALLENGER is a free data retrieval call binding the contract method 0x6b4d98dd.
+//
+// Solidity: function CHALLENGER() view returns(address)
+func (_Oracle *OracleCaller) CHALLENGER(opts *bind.CallOpts) (common.Address, error) {
+}
+
+// Version is a free data retrieval call binding the contract method 0x54fd4d50.
+//
+// Solidity: function version() view returns(string)
+func (_Oracle *OracleCaller) Version(opts *bind.CallOpts) (string, error) {
+}
+
+// FilterInitialized is a free log retrieval operation binding the contract event 0x7f26b83ff96e1f2b6a682f133852f6798a09c465da95921460cefb3847402498.
+//
+// Solidity: event Initialized(uint8 version)
+func (_Oracle *OracleFilterer) FilterInitialized(opts *bind.FilterOpts) (*OracleInitializedIterator, error) {
+}
+`
+	artifact := &artifactData{
+		UserDoc: userDoc{
+			Notice: "Stores L2 output roots.",
+			Methods: map[string]userDocEntry{
+				"CHALLENGER()": {Notice: "Address of the challenger."},
+			},
+			Events: map[string]userDocEntry{
+				"Initialized(uint8)": {Notice: "Emitted when the contract\n   is initialized."},
+			},
+		},
+		DevDoc: devDoc{
+			Title: "Oracle",
+			Methods: map[string]devDocEntry{
+				"CHALLENGER()": {Details: "Deprecated, use challenger() instead."},
+			},
+		},
+	}
+
+	expected := `// Oracle is an auto generated Go binding around an Ethereum contract.
+//
+// Oracle
+//
+// Stores L2 output roots.
+type Oracle struct {
+}
+
+// CHALLENGER is a free data retrieval call binding the contract method 0x6b4d98dd.
+//
+// Solidity: function CHALLENGER() view returns(address)
+//
+// Address of the challenger.
+//
+// Deprecated, use challenger() instead.
+func (_Oracle *OracleCaller) CHALLENGER(opts *bind.CallOpts) (common.Address, error) {
+}
+
+// Version is a free data retrieval call binding the contract method 0x54fd4d50.
+//
+// Solidity: function version() view returns(string)
+func (_Oracle *OracleCaller) Version(opts *bind.CallOpts) (string, error) {
+}
+
+// FilterInitialized is a free log retrieval operation binding the contract event 0x7f26b83ff96e1f2b6a682f133852f6798a09c465da95921460cefb3847402498.
+//
+// Solidity: event Initialized(uint8 version)
+//
+// Emitted when the contract
+// is initialized.
+func (_Oracle *OracleFilterer) FilterInitialized(opts *bind.FilterOpts) (*OracleInitializedIterator, error) {
+}
+`
+	require.Equal(t, expected, string(addNatSpec([]byte(bindings), "Oracle", artifact)))
+}
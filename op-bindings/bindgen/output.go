@@ -0,0 +1,344 @@
+package bindgen
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Supported values of LocalConfig.FilenameScheme.
+const (
+	FilenameSchemeLower    = "lower"
+	FilenameSchemeSnake    = "snake"
+	FilenameSchemeOriginal = "original"
+)
+
+// fileBase returns the name, without extension, of the files generated for a
+// contract. Files are named after the Go type name of the bindings.
+func (g *generator) fileBase(name string) string {
+	name = g.typeName(name)
+	switch g.FilenameScheme {
+	case FilenameSchemeSnake:
+		return snakeCase(name)
+	case FilenameSchemeOriginal:
+		return name
+	default:
+		return strings.ToLower(name)
+	}
+}
+
+// snakeCase converts a contract name such as L2OutputOracle to l2_output_oracle.
+// A word starts at an upper case letter following a lower case letter or a
+// digit, or at the last upper case letter of an acronym followed by a lower
+// case letter.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// sourceHashPrefix starts the line of the header of generated Go files that
+// holds the hash of the inputs they were generated from.
+const sourceHashPrefix = "// Source hash: "
+
+// stampSourceHash adds the source hash line to the header of a generated Go
+// file, after its leading comments. A file without leading comments gets a
+// header of its own.
+func stampSourceHash(src []byte, hash common.Hash) []byte {
+	line := sourceHashPrefix + hash.Hex() + "\n"
+	end := 0
+	for bytes.HasPrefix(src[end:], []byte("//")) {
+		i := bytes.IndexByte(src[end:], '\n')
+		if i < 0 {
+			break
+		}
+		end += i + 1
+	}
+	stamped := make([]byte, 0, len(src)+len(line)+1)
+	stamped = append(stamped, src[:end]...)
+	stamped = append(stamped, line...)
+	if end == 0 {
+		stamped = append(stamped, '\n')
+	}
+	return append(stamped, src[end:]...)
+}
+
+// readSourceHash returns the source hash in the header of a generated Go
+// file, or the zero hash when it has none.
+func readSourceHash(path string) (common.Hash, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadString('\n')
+		if !strings.HasPrefix(line, "//") {
+			return common.Hash{}, nil
+		}
+		if hex, ok := strings.CutPrefix(strings.TrimSpace(line), sourceHashPrefix); ok {
+			var hash common.Hash
+			if err := hash.UnmarshalText([]byte(hex)); err != nil {
+				return common.Hash{}, fmt.Errorf("invalid source hash: %w", err)
+			}
+			return hash, nil
+		}
+		if err == io.EOF {
+			return common.Hash{}, nil
+		} else if err != nil {
+			return common.Hash{}, err
+		}
+	}
+}
+
+// checkOutputCollisions returns an error when several contracts would be
+// written to the same file, which would silently overwrite all but one.
+func (g *generator) checkOutputCollisions(names map[string]string) error {
+	owners := make(map[string][]string)
+	for name := range names {
+		files := []string{g.bindingsFile(name)}
+		if _, interfaceOnly := g.interfaceSet[name]; !g.Combined && !interfaceOnly {
+			files = append(files, g.metadataFile(name))
+		}
+		for _, file := range files {
+			abs, err := filepath.Abs(file)
+			if err != nil {
+				return err
+			}
+			owners[abs] = append(owners[abs], name)
+		}
+	}
+
+	var collisions []string
+	for file, contracts := range owners {
+		if len(contracts) > 1 {
+			sort.Strings(contracts)
+			collisions = append(collisions, fmt.Sprintf("%s is generated for %s", file, strings.Join(contracts, ", ")))
+		}
+	}
+	if len(collisions) == 0 {
+		return nil
+	}
+	sort.Strings(collisions)
+	return fmt.Errorf("contracts would overwrite each other's files, use a different filename scheme:\n%s", strings.Join(collisions, "\n"))
+}
+
+// writeOutput writes a generated file. In dry-run mode nothing is written and
+// the difference with the existing file is logged instead, and in check mode
+// the file is recorded as stale when it differs from the existing one.
+func (g *generator) writeOutput(path string, data []byte) error {
+	if g.Check {
+		return g.checkOutput(path, data)
+	}
+	if g.DryRun {
+		change, err := describeChange(path, data)
+		if err != nil {
+			return err
+		}
+		g.logger.Info("Dry run", "change", change)
+		return nil
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	g.logger.Debug("Wrote file", "path", path)
+	g.summary.bytesWritten.Add(int64(len(data)))
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// over path, so that an interrupted run never leaves a truncated file behind.
+// The temporary file starts with a dot so that the go tool ignores it. The
+// file keeps the mode of the file it replaces, new files are made 0644.
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// describeChange describes how writing data to path would change the file.
+func describeChange(path string, data []byte) (string, error) {
+	existing, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Sprintf("would create %s (%d bytes)", path, len(data)), nil
+	} else if err != nil {
+		return "", fmt.Errorf("error reading %s: %w", path, err)
+	}
+	if bytes.Equal(existing, data) {
+		return fmt.Sprintf("%s is unchanged", path), nil
+	}
+
+	offset := 0
+	for offset < len(existing) && offset < len(data) && existing[offset] == data[offset] {
+		offset++
+	}
+	line := bytes.Count(data[:offset], []byte("\n")) + 1
+	return fmt.Sprintf("would update %s (%d bytes -> %d bytes, first difference at byte %d on line %d)",
+		path, len(existing), len(data), offset, line), nil
+}
+
+// checkOutput records path as stale when its contents on disk are not data.
+func (g *generator) checkOutput(path string, data []byte) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error reading %s: %w", path, err)
+	}
+	if err == nil && bytes.Equal(existing, data) {
+		g.logger.Debug("Generated file is up to date", "path", path)
+		return nil
+	}
+	change, err := describeChange(path, data)
+	if err != nil {
+		return err
+	}
+	g.logger.Warn("Generated file is stale", "change", change)
+	diff := ""
+	if g.CheckDiff {
+		if diff, err = unifiedDiff(path, existing, data); err != nil {
+			return fmt.Errorf("error diffing %s: %w", path, err)
+		}
+	}
+	g.stale.add(path, diff)
+	return nil
+}
+
+// storageLayoutJSONRe matches the storage layout constant of a metadata file.
+var storageLayoutJSONRe = regexp.MustCompile(`(?m)^(const \w+StorageLayoutJSON = )("(?:[^"\\]|\\.)*")$`)
+
+// unifiedDiff returns the unified diff from the file at path on disk, holding
+// existing, to the generated data. Storage layouts are pretty-printed first
+// so that the diff shows the changed slots rather than a single long line.
+func unifiedDiff(path string, existing, data []byte) (string, error) {
+	if !utf8.Valid(existing) || !utf8.Valid(data) {
+		return fmt.Sprintf("Binary files %s and %s (generated) differ\n", path, path), nil
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(expandStorageLayouts(string(existing))),
+		B:        difflib.SplitLines(expandStorageLayouts(string(data))),
+		FromFile: path,
+		ToFile:   path + " (generated)",
+		Context:  3,
+	})
+}
+
+// expandStorageLayouts pretty-prints the storage layout constants of a
+// metadata file. The result is only meant to be diffed, it is not valid Go.
+func expandStorageLayouts(src string) string {
+	return storageLayoutJSONRe.ReplaceAllStringFunc(src, func(line string) string {
+		m := storageLayoutJSONRe.FindStringSubmatch(line)
+		layout, err := strconv.Unquote(m[2])
+		if err != nil {
+			return line
+		}
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, []byte(layout), "", "  "); err != nil {
+			return line
+		}
+		return m[1] + indented.String()
+	})
+}
+
+// staleFiles collects the generated files that differ from the files on disk
+// in check mode, along with their diffs when CheckDiff is set. It is safe for
+// concurrent use.
+type staleFiles struct {
+	mu    sync.Mutex
+	paths []string
+	diffs map[string]string
+}
+
+func (s *staleFiles) add(path, diff string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paths = append(s.paths, path)
+	if diff != "" {
+		if s.diffs == nil {
+			s.diffs = make(map[string]string)
+		}
+		s.diffs[path] = diff
+	}
+}
+
+// writeDiffs writes the diffs of the stale files to w, sorted by path.
+func (s *staleFiles) writeDiffs(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sort.Strings(s.paths)
+	for _, path := range s.paths {
+		if diff, ok := s.diffs[path]; ok {
+			if _, err := io.WriteString(w, diff); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// err returns an error listing the stale files, or nil when there are none.
+func (s *staleFiles) err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.paths) == 0 {
+		return nil
+	}
+	sort.Strings(s.paths)
+	return fmt.Errorf("%d generated files are stale, regenerate the bindings:\n%s", len(s.paths), strings.Join(s.paths, "\n"))
+}
@@ -0,0 +1,198 @@
+package bindgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// packageRegistryFile is the name of the file that declares the registry of
+// a package that contracts are routed to with the package option of the
+// contracts list.
+const packageRegistryFile = "bindings_registry.go"
+
+// packageOf returns the Go package the bindings and metadata of a contract
+// are generated in.
+func (g *generator) packageOf(name string) string {
+	if pkg, ok := g.packages[name]; ok {
+		return pkg
+	}
+	return g.Package
+}
+
+// bindingsPackageOf returns the Go package the bindings of a contract are
+// generated in.
+func (g *generator) bindingsPackageOf(name string) string {
+	if pkg, ok := g.packages[name]; ok {
+		return pkg
+	}
+	return g.bindingsPackage
+}
+
+// checkPackageClause returns an error when a Go file in dir declares a
+// different package than pkg, since the bindings would not compile with it. A
+// missing directory has no Go files yet.
+func checkPackageClause(dir, pkg string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.PackageClauseOnly)
+		if err != nil {
+			return fmt.Errorf("error reading package clause: %w", err)
+		}
+		if f.Name.Name != pkg {
+			return fmt.Errorf("%s declares package %s, not %s", file, f.Name.Name, pkg)
+		}
+	}
+	return nil
+}
+
+// packageDir returns the directory of a package that contracts are routed to.
+// It holds both the bindings and the metadata of those contracts, next to the
+// metadata directory.
+func (g *generator) packageDir(pkg string) string {
+	return filepath.Join(g.packagesDir, pkg)
+}
+
+// sharesDir reports whether the bindings and the metadata of a contract are
+// written to the same directory, so that the metadata can refer to the
+// declarations of the bindings.
+func (g *generator) sharesDir(name string) bool {
+	if _, ok := g.packages[name]; ok {
+		return true
+	}
+	return g.sharedDir
+}
+
+// routedPackages returns the sorted packages that contracts are routed to.
+func (g *generator) routedPackages() []string {
+	seen := make(map[string]struct{})
+	var pkgs []string
+	for _, pkg := range g.packages {
+		if _, ok := seen[pkg]; !ok {
+			seen[pkg] = struct{}{}
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	sort.Strings(pkgs)
+	return pkgs
+}
+
+// createPackageDirs creates the directories of the routed packages, unless
+// nothing is written in this run.
+func (g *generator) createPackageDirs() error {
+	if g.DryRun || g.Check {
+		return nil
+	}
+	for _, pkg := range g.routedPackages() {
+		if err := os.MkdirAll(g.packageDir(pkg), 0o755); err != nil {
+			return fmt.Errorf("error creating package directory: %w", err)
+		}
+	}
+	return nil
+}
+
+// writePackageRegistries writes the registry that the metadata files of every
+// routed package populate. The package of Package has a hand-written one.
+func (g *generator) writePackageRegistries() error {
+	t, err := template.New("registry").Parse(registryTmpl)
+	if err != nil {
+		return err
+	}
+	for _, pkg := range g.routedPackages() {
+		registryFile := filepath.Join(g.packageDir(pkg), packageRegistryFile)
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, struct{ Package string }{pkg}); err != nil {
+			return fmt.Errorf("error writing template %s: %w", registryFile, err)
+		}
+		formatted, err := format.Source(buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("error formatting %s: %w", registryFile, err)
+		}
+		// The registry only depends on the package, so an existing one is
+		// left alone rather than rewritten with the same contents.
+		if existing, err := os.ReadFile(registryFile); err == nil && bytes.Equal(existing, formatted) {
+			g.logger.Debug("Registry is up to date", "path", registryFile)
+			continue
+		}
+		if err := g.writeOutput(registryFile, formatted); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registryTmpl declares the maps that the metadata files of a package
+// populate, and the accessors of the package. The accessors are implemented
+// by the registry package, as for the hand-written registry of Package.
+var registryTmpl = `// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package {{.Package}}
+
+import (
+	"github.com/ethereum-optimism/optimism/op-bindings/registry"
+	"github.com/ethereum-optimism/optimism/op-bindings/solc"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+var contracts = registry.New()
+
+var layouts = contracts.Layouts
+
+var deployedBytecodes = contracts.DeployedBytecodes
+
+var creationBytecodes = contracts.CreationBytecodes
+
+var abis = contracts.ABIs
+
+var compilerSettings = contracts.Settings
+
+// CompilerSettings is the compiler configuration a contract was built with.
+type CompilerSettings = registry.CompilerSettings
+
+var deployedSourceMaps = contracts.DeployedSourceMaps
+
+// GetStorageLayout returns the storage layout of a contract by name.
+func GetStorageLayout(name string) (*solc.StorageLayout, error) {
+	return contracts.StorageLayout(name)
+}
+
+// GetDeployedBytecode returns the deployed bytecode of a contract by name.
+func GetDeployedBytecode(name string) ([]byte, error) {
+	return contracts.DeployedBytecode(name)
+}
+
+// GetCreationBytecode returns the creation bytecode of a contract by name.
+func GetCreationBytecode(name string) ([]byte, error) {
+	return contracts.CreationBytecode(name)
+}
+
+// GetABI returns the parsed ABI of a contract by name.
+func GetABI(name string) (*abi.ABI, error) {
+	return contracts.ABI(name)
+}
+
+// GetCompilerSettings returns the compiler settings of a contract by name.
+func GetCompilerSettings(name string) (CompilerSettings, error) {
+	return contracts.CompilerSettings(name)
+}
+
+// GetDeployedSourceMap returns the deployed source map of a contract by name,
+// for contracts whose source map is generated as a separate file.
+func GetDeployedSourceMap(name string) (string, error) {
+	return contracts.DeployedSourceMap(name)
+}
+`
@@ -0,0 +1,48 @@
+package bindgen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimism/op-service/testlog"
+)
+
+func TestPackageRouting(t *testing.T) {
+	dir := t.TempDir()
+	outDir := filepath.Join(dir, "bindings")
+	g := &generator{
+		LocalConfig:     LocalConfig{OutDir: outDir, Package: "bindings", FilenameScheme: FilenameSchemeLower},
+		logger:          testlog.Logger(t, log.LvlInfo),
+		bindingsDir:     filepath.Join(dir, "abi"),
+		bindingsPackage: "abi",
+		packagesDir:     dir,
+		packages:        map[string]string{"L1Block": "l2bindings", "Proxy": "common", "ProxyAdmin": "common"},
+	}
+
+	require.Equal(t, "bindings", g.packageOf("Foo"))
+	require.Equal(t, "abi", g.bindingsPackageOf("Foo"))
+	require.Equal(t, filepath.Join(dir, "abi", "foo.go"), g.bindingsFile("Foo"))
+	require.Equal(t, filepath.Join(outDir, "foo_more.go"), g.metadataFile("Foo"))
+	require.False(t, g.sharesDir("Foo"))
+
+	require.Equal(t, "l2bindings", g.packageOf("L1Block"))
+	require.Equal(t, "l2bindings", g.bindingsPackageOf("L1Block"))
+	require.Equal(t, filepath.Join(dir, "l2bindings", "l1block.go"), g.bindingsFile("L1Block"))
+	require.Equal(t, filepath.Join(dir, "l2bindings", "l1block_more.go"), g.metadataFile("L1Block"))
+	require.True(t, g.sharesDir("L1Block"))
+
+	require.Equal(t, []string{"common", "l2bindings"}, g.routedPackages())
+	require.NoError(t, g.createPackageDirs())
+	require.NoError(t, g.writePackageRegistries())
+	for _, pkg := range []string{"common", "l2bindings"} {
+		registry, err := os.ReadFile(filepath.Join(dir, pkg, packageRegistryFile))
+		require.NoError(t, err)
+		require.Contains(t, string(registry), "package "+pkg+"\n")
+		require.Contains(t, string(registry), "var layouts = contracts.Layouts")
+		require.NotContains(t, string(registry), "func isHex", "the accessors are implemented by the registry package")
+	}
+}
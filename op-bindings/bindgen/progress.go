@@ -0,0 +1,47 @@
+package bindgen
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// progressLogInterval is the minimum time between two progress logs.
+const progressLogInterval = 10 * time.Second
+
+// progress periodically logs how many of the contracts of a run have been
+// processed. It is safe for concurrent use.
+type progress struct {
+	logger   log.Logger
+	total    int
+	interval time.Duration
+	start    time.Time
+
+	mu        sync.Mutex
+	processed int
+	lastLog   time.Time
+}
+
+func newProgress(logger log.Logger, total int, interval time.Duration) *progress {
+	now := time.Now()
+	return &progress{logger: logger, total: total, interval: interval, start: now, lastLog: now}
+}
+
+// contractProcessed records that a contract was generated, skipped or failed,
+// and logs the progress of the run when the interval has passed since the
+// last log. The last contract is not logged, since the summary follows.
+func (p *progress) contractProcessed() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.processed++
+	now := time.Now()
+	if p.processed >= p.total || now.Sub(p.lastLog) < p.interval {
+		return
+	}
+	p.lastLog = now
+	elapsed := now.Sub(p.start)
+	remaining := time.Duration(float64(elapsed) / float64(p.processed) * float64(p.total-p.processed))
+	p.logger.Info("Generation progress", "processed", p.processed, "total", p.total,
+		"percent", p.processed*100/p.total, "elapsed", elapsed.Round(time.Second), "remaining", remaining.Round(time.Second))
+}
@@ -0,0 +1,34 @@
+package bindgen
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimism/op-service/testlog"
+)
+
+func TestProgress(t *testing.T) {
+	logger := testlog.Logger(t, log.LvlInfo)
+	logs := testlog.Capture(logger)
+
+	p := newProgress(logger, 4, 0)
+	p.contractProcessed()
+	record := logs.FindLog(log.LvlInfo, "Generation progress")
+	require.NotNil(t, record)
+	require.Equal(t, 1, record.GetContextValue("processed"))
+	require.Equal(t, 4, record.GetContextValue("total"))
+	require.Equal(t, 25, record.GetContextValue("percent"))
+
+	logs.Clear()
+	p.contractProcessed()
+	p.contractProcessed()
+	p.contractProcessed()
+	require.Len(t, logs.Logs, 2, "the last contract is not logged")
+
+	logs.Clear()
+	p = newProgress(logger, 4, progressLogInterval)
+	p.contractProcessed()
+	require.Empty(t, logs.Logs, "progress is only logged once the interval has passed")
+}
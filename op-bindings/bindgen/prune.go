@@ -0,0 +1,137 @@
+package bindgen
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// generatedFileHeader is the first line of the bindings written by abigen and
+// of the metadata files. Only files starting with it are pruned.
+const generatedFileHeader = "// Code generated - DO NOT EDIT."
+
+// generatedFiles returns the absolute paths of the Go files that the
+// contracts are generated to, whether or not they are up to date.
+func (g *generator) generatedFiles(names []string) (map[string]struct{}, error) {
+	var paths []string
+	for _, name := range names {
+		paths = append(paths, g.bindingsFile(name))
+		if _, interfaceOnly := g.interfaceSet[name]; !interfaceOnly {
+			paths = append(paths, g.metadataFile(name))
+		}
+	}
+	if g.Combined {
+		paths = append(paths, filepath.Join(g.OutDir, combinedMetadataFile))
+	}
+	for _, pkg := range g.routedPackages() {
+		paths = append(paths, filepath.Join(g.packageDir(pkg), packageRegistryFile))
+	}
+
+	files := make(map[string]struct{}, len(paths))
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		files[abs] = struct{}{}
+	}
+	return files, nil
+}
+
+// prune deletes the generated Go files in the output directories that none of
+// the contracts is generated to anymore, such as the files of contracts that
+// were removed from the contracts list. Files that do not start with the
+// generated file header are never deleted. In check mode the orphaned files
+// are reported as stale instead.
+func (g *generator) prune(names []string) error {
+	files, err := g.generatedFiles(names)
+	if err != nil {
+		return err
+	}
+	dirs := []string{g.OutDir, g.bindingsDir}
+	for _, pkg := range g.routedPackages() {
+		dirs = append(dirs, g.packageDir(pkg))
+	}
+
+	var orphans []string
+	seen := make(map[string]struct{})
+	for _, dir := range dirs {
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		if _, ok := seen[dir]; ok {
+			continue
+		}
+		seen[dir] = struct{}{}
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("error reading %s: %w", dir, err)
+		}
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if !entry.Type().IsRegular() || filepath.Ext(path) != ".go" {
+				continue
+			}
+			if _, ok := files[path]; ok {
+				continue
+			}
+			generated, err := isGeneratedFile(path)
+			if err != nil {
+				return err
+			}
+			if generated {
+				orphans = append(orphans, path)
+			}
+		}
+	}
+	sort.Strings(orphans)
+
+	for _, path := range orphans {
+		change := fmt.Sprintf("would delete %s", path)
+		switch {
+		case g.Check:
+			g.logger.Warn("Generated file is stale", "change", change)
+			diff := ""
+			if g.CheckDiff {
+				existing, err := os.ReadFile(path)
+				if err != nil {
+					return fmt.Errorf("error reading %s: %w", path, err)
+				}
+				if diff, err = unifiedDiff(path, existing, nil); err != nil {
+					return fmt.Errorf("error diffing %s: %w", path, err)
+				}
+			}
+			g.stale.add(path, diff)
+		case g.DryRun:
+			g.logger.Info("Dry run", "change", change)
+		default:
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("error pruning %s: %w", path, err)
+			}
+			g.logger.Info("Pruned orphaned generated file", "path", path)
+		}
+	}
+	return nil
+}
+
+// isGeneratedFile reports whether the file at path starts with the generated
+// file header.
+func isGeneratedFile(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && line == "" {
+		return false, nil
+	}
+	return strings.TrimRight(line, "\r\n") == generatedFileHeader, nil
+}
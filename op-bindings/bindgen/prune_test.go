@@ -0,0 +1,75 @@
+package bindgen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimism/op-service/testlog"
+)
+
+func TestPrune(t *testing.T) {
+	setup := func(t *testing.T) (*generator, string) {
+		dir := t.TempDir()
+		g := &generator{
+			LocalConfig: LocalConfig{OutDir: dir, FilenameScheme: FilenameSchemeLower},
+			logger:      testlog.Logger(t, log.LvlInfo),
+			bindingsDir: dir,
+			sharedDir:   true,
+		}
+		generated := generatedFileHeader + "\n\npackage bindings\n"
+		for name, content := range map[string]string{
+			"foo.go":      generated,
+			"foo_more.go": generated,
+			"old.go":      generated,
+			"old_more.go": generated,
+			"registry.go": "package bindings\n",
+			"notes.txt":   generated,
+		} {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+		}
+		return g, dir
+	}
+
+	t.Run("deletes orphaned generated files", func(t *testing.T) {
+		g, dir := setup(t)
+		require.NoError(t, g.prune([]string{"Foo"}))
+		require.FileExists(t, filepath.Join(dir, "foo.go"))
+		require.FileExists(t, filepath.Join(dir, "foo_more.go"))
+		require.NoFileExists(t, filepath.Join(dir, "old.go"))
+		require.NoFileExists(t, filepath.Join(dir, "old_more.go"))
+		require.FileExists(t, filepath.Join(dir, "registry.go"), "hand-written files are never pruned")
+		require.FileExists(t, filepath.Join(dir, "notes.txt"))
+	})
+
+	t.Run("check mode reports orphaned files as stale", func(t *testing.T) {
+		g, dir := setup(t)
+		g.Check = true
+		require.NoError(t, g.prune([]string{"Foo"}))
+		require.FileExists(t, filepath.Join(dir, "old.go"))
+		require.ErrorContains(t, g.stale.err(), "2 generated files are stale")
+	})
+
+	t.Run("relative and absolute directories", func(t *testing.T) {
+		g, dir := setup(t)
+		wd, err := os.Getwd()
+		require.NoError(t, err)
+		rel, err := filepath.Rel(wd, dir)
+		require.NoError(t, err)
+		g.bindingsDir = rel
+		require.NoError(t, g.prune([]string{"Foo"}))
+		require.FileExists(t, filepath.Join(dir, "foo.go"))
+		require.FileExists(t, filepath.Join(dir, "foo_more.go"))
+		require.NoFileExists(t, filepath.Join(dir, "old.go"))
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		g, dir := setup(t)
+		g.DryRun = true
+		require.NoError(t, g.prune([]string{"Foo"}))
+		require.FileExists(t, filepath.Join(dir, "old_more.go"))
+	})
+}
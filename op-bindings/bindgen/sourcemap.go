@@ -0,0 +1,41 @@
+package bindgen
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+)
+
+// Supported values of LocalConfig.SourceMapMode.
+const (
+	// SourceMapModeEmbed embeds deployed source maps as string variables.
+	SourceMapModeEmbed = "embed"
+	// SourceMapModeFile writes deployed source maps to gzip compressed files
+	// that are embedded as bytes and registered in deployedSourceMaps, so
+	// they are only decompressed when requested.
+	SourceMapModeFile = "file"
+	// SourceMapModeNone leaves out deployed source maps entirely.
+	SourceMapModeNone = "none"
+)
+
+// sourceMapFileSuffix is appended to the file base of a contract to name the
+// file its deployed source map is written to with SourceMapModeFile.
+const sourceMapFileSuffix = ".srcmap.gz"
+
+// writeSourceMapFile writes a gzip compressed deployed source map to path. The
+// gzip header carries no name or modification time, so the output only
+// depends on the source map.
+func (g *generator) writeSourceMapFile(path, sourceMap string) error {
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte(sourceMap)); err != nil {
+		return fmt.Errorf("error compressing source map %s: %w", path, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("error compressing source map %s: %w", path, err)
+	}
+	return g.writeOutput(path, buf.Bytes())
+}
@@ -0,0 +1,55 @@
+package bindgen
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimism/op-service/testlog"
+)
+
+func TestWriteSourceMapFile(t *testing.T) {
+	dir := t.TempDir()
+	g := &generator{logger: testlog.Logger(t, log.LvlInfo)}
+	sourceMap := "0:100:1:-:0;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;"
+
+	first := filepath.Join(dir, "first.srcmap.gz")
+	second := filepath.Join(dir, "second.srcmap.gz")
+	require.NoError(t, g.writeSourceMapFile(first, sourceMap))
+	require.NoError(t, g.writeSourceMapFile(second, sourceMap))
+
+	compressed, err := os.ReadFile(first)
+	require.NoError(t, err)
+	other, err := os.ReadFile(second)
+	require.NoError(t, err)
+	require.Equal(t, compressed, other, "compressed source maps must be deterministic")
+	require.Less(t, len(compressed), len(sourceMap))
+
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	require.NoError(t, err)
+	decompressed, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, sourceMap, string(decompressed))
+}
+
+func TestSourceMapFileTemplate(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, err := loadTemplate("", false)
+	require.NoError(t, err)
+	g := &generator{tmpl: tmpl, logger: testlog.Logger(t, log.LvlInfo)}
+
+	metadataFile := filepath.Join(dir, "foo_more.go")
+	require.NoError(t, g.writeMetadata(metadataFile, data{Name: "Foo", Contract: "Foo", Package: "bindings", StorageLayout: "{}", DeployedBin: "0x01", DeployedSourceMapFile: "foo.srcmap.gz"}))
+	out, err := os.ReadFile(metadataFile)
+	require.NoError(t, err)
+	require.Contains(t, string(out), "\t_ \"embed\"\n")
+	require.Contains(t, string(out), "//go:embed foo.srcmap.gz\nvar FooDeployedSourceMapGz []byte\n")
+	require.Contains(t, string(out), `deployedSourceMaps["Foo"] = FooDeployedSourceMapGz`)
+	require.NotContains(t, string(out), "FooDeployedSourceMap =")
+}
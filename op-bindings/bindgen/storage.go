@@ -0,0 +1,250 @@
+package bindgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/solc"
+)
+
+// storageLayoutFile returns the path of the storage layout of a contract in
+// the StorageLayoutDir, or "" when it is not set.
+func (g *generator) storageLayoutFile(name string) string {
+	if g.StorageLayoutDir == "" {
+		return ""
+	}
+	return filepath.Join(g.StorageLayoutDir, name+".json")
+}
+
+// storageLayout returns the storage layout of a contract from its forge
+// artifact, or from the StorageLayoutDir when the artifact was built without
+// the storageLayout extra output.
+func (g *generator) storageLayout(name string, a *artifactData) (solc.StorageLayout, error) {
+	if a.hasStorageLayout() {
+		layout, err := decodeStorageLayout(a.StorageLayout)
+		if err != nil {
+			return solc.StorageLayout{}, fmt.Errorf("invalid storage layout in forge artifact of %q: %w", name, err)
+		}
+		return layout, nil
+	}
+
+	layoutFile := g.storageLayoutFile(name)
+	if layoutFile == "" {
+		return solc.StorageLayout{}, fmt.Errorf("forge artifact of %q has no storage layout, build it with --extra-output storageLayout", name)
+	}
+	layoutData, err := os.ReadFile(layoutFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return solc.StorageLayout{}, fmt.Errorf("forge artifact of %q has no storage layout and %s does not exist, build it with --extra-output storageLayout", name, layoutFile)
+	} else if err != nil {
+		return solc.StorageLayout{}, fmt.Errorf("error reading storage layout of %q: %w", name, err)
+	}
+	layout, err := decodeStorageLayout(layoutData)
+	if err != nil {
+		return solc.StorageLayout{}, fmt.Errorf("invalid storage layout %s: %w", layoutFile, err)
+	}
+	g.logger.Debug("Using storage layout file", "contract", name, "path", layoutFile)
+	return layout, nil
+}
+
+// marshalStorageLayout encodes a canonical storage layout so that the output
+// only depends on its contents. Variables are ordered by slot and offset, and
+// type keys are sorted by encoding/json. A missing types map is encoded as
+// empty rather than null, while contracts without storage keep encoding it as
+// null, as in the committed metadata.
+func marshalStorageLayout(layout *solc.StorageLayout) ([]byte, error) {
+	out := solc.StorageLayout{
+		Types: layout.Types,
+	}
+	if layout.Storage != nil {
+		out.Storage = append([]solc.StorageLayoutEntry{}, layout.Storage...)
+	}
+	if out.Types == nil {
+		out.Types = map[string]solc.StorageLayoutType{}
+	}
+	sort.SliceStable(out.Storage, func(i, j int) bool {
+		a, b := out.Storage[i], out.Storage[j]
+		if a.Slot != b.Slot {
+			return a.Slot < b.Slot
+		}
+		return a.Offset < b.Offset
+	})
+	// Labels are written as is, quoteStorageLayout escapes them for Go.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(out); err != nil {
+		return nil, fmt.Errorf("error marshaling storage: %w", err)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// htmlEscaper writes the characters that encoding/json escapes by default as
+// unicode escapes, as in the committed metadata.
+var htmlEscaper = strings.NewReplacer("<", `\u003c`, ">", `\u003e`, "&", `\u0026`)
+
+// quoteStorageLayout returns the contents of a Go string literal holding the
+// storage layout JSON ser, as encoded by marshalStorageLayout. The literal is
+// unquoted and decoded again before it is used, so that labels with quotes,
+// backslashes or newlines cannot produce metadata that does not compile or
+// that panics in its init function.
+func quoteStorageLayout(ser []byte) (string, error) {
+	quoted := htmlEscaper.Replace(strconv.Quote(string(ser)))
+	unquoted, err := strconv.Unquote(quoted)
+	if err != nil {
+		return "", fmt.Errorf("cannot unquote storage layout: %w", err)
+	}
+	layout, err := decodeStorageLayout([]byte(unquoted))
+	if err != nil {
+		return "", err
+	}
+	reencoded, err := marshalStorageLayout(&layout)
+	if err != nil {
+		return "", err
+	}
+	if !bytes.Equal(reencoded, ser) {
+		return "", errors.New("storage layout does not round-trip through its Go literal")
+	}
+	return quoted[1 : len(quoted)-1], nil
+}
+
+// storageLayoutType is a type of the storage layout of a forge artifact. It
+// holds the members of structs, which solc emits but the metadata drops.
+type storageLayoutType struct {
+	solc.StorageLayoutType
+	Members json.RawMessage `json:"members"`
+}
+
+// decodeStorageLayout decodes the storage layout JSON of a forge artifact.
+// Fields that solc.StorageLayout does not know about fail the decoding: they
+// come from an incompatible foundry version, whose layouts would silently lose
+// information or make the generated init function panic.
+func decodeStorageLayout(data []byte) (solc.StorageLayout, error) {
+	var decoded struct {
+		Storage []solc.StorageLayoutEntry    `json:"storage"`
+		Types   map[string]storageLayoutType `json:"types"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&decoded); err != nil {
+		return solc.StorageLayout{}, fmt.Errorf("cannot decode storage layout: %w", err)
+	}
+	layout := solc.StorageLayout{Storage: decoded.Storage}
+	if decoded.Types != nil {
+		layout.Types = make(map[string]solc.StorageLayoutType, len(decoded.Types))
+		for name, typ := range decoded.Types {
+			layout.Types[name] = typ.StorageLayoutType
+		}
+	}
+	return layout, nil
+}
+
+// validateStorageLayout checks that every variable and type of a storage
+// layout refers to types that the layout defines, with a known encoding.
+func validateStorageLayout(layout *solc.StorageLayout) error {
+	var problems []error
+	for _, entry := range layout.Storage {
+		if entry.Label == "" {
+			problems = append(problems, fmt.Errorf("variable in slot %d has no label", entry.Slot))
+		}
+		if _, ok := layout.Types[entry.Type]; !ok {
+			problems = append(problems, fmt.Errorf("variable %q has unknown type %q", entry.Label, entry.Type))
+		}
+	}
+	for name, typ := range layout.Types {
+		if typ.NumberOfBytes == 0 {
+			problems = append(problems, fmt.Errorf("type %q has no size", name))
+		}
+		var refs []string
+		switch typ.Encoding {
+		case "inplace", "bytes":
+		case "mapping":
+			refs = []string{typ.Key, typ.Value}
+		case "dynamic_array":
+			refs = []string{typ.Base}
+		default:
+			problems = append(problems, fmt.Errorf("type %q has unknown encoding %q", name, typ.Encoding))
+		}
+		for _, ref := range refs {
+			// Older artifacts leave out the base type of some arrays.
+			if ref == "" {
+				continue
+			}
+			if _, ok := layout.Types[ref]; !ok {
+				problems = append(problems, fmt.Errorf("type %q refers to unknown type %q", name, ref))
+			}
+		}
+	}
+	sort.Slice(problems, func(i, j int) bool {
+		return problems[i].Error() < problems[j].Error()
+	})
+	return errors.Join(problems...)
+}
+
+// readCommittedStorageLayout reads the storage layout of a contract from a
+// previously generated metadata file. It returns nil when the file does not
+// exist yet.
+func readCommittedStorageLayout(metadataFile, name string) (*solc.StorageLayout, error) {
+	data, err := os.ReadFile(metadataFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", metadataFile, err)
+	}
+
+	re := regexp.MustCompile(`const ` + regexp.QuoteMeta(name) + `StorageLayoutJSON = (".*")`)
+	matches := re.FindSubmatch(data)
+	if matches == nil {
+		return nil, fmt.Errorf("cannot find %sStorageLayoutJSON in %s", name, metadataFile)
+	}
+	raw, err := strconv.Unquote(string(matches[1]))
+	if err != nil {
+		return nil, fmt.Errorf("error unquoting storage layout of %q: %w", name, err)
+	}
+	var layout solc.StorageLayout
+	if err := json.Unmarshal([]byte(raw), &layout); err != nil {
+		return nil, fmt.Errorf("error parsing storage layout of %q: %w", name, err)
+	}
+	return &layout, nil
+}
+
+// storageLayoutChanges returns a description of every change from prev to
+// next that breaks storage compatibility of an upgradeable contract. Variables
+// are matched by label. Removing a variable, moving it to a different slot or
+// offset, or changing its type are all considered incompatible. Appending new
+// variables is not.
+func storageLayoutChanges(prev, next *solc.StorageLayout) []string {
+	nextEntries := make(map[string]solc.StorageLayoutEntry, len(next.Storage))
+	for _, entry := range next.Storage {
+		nextEntries[entry.Label] = entry
+	}
+
+	var changes []string
+	for _, prevEntry := range prev.Storage {
+		nextEntry, ok := nextEntries[prevEntry.Label]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("%s: removed from slot %d offset %d", prevEntry.Label, prevEntry.Slot, prevEntry.Offset))
+			continue
+		}
+		if prevEntry.Slot != nextEntry.Slot || prevEntry.Offset != nextEntry.Offset {
+			changes = append(changes, fmt.Sprintf("%s: moved from slot %d offset %d to slot %d offset %d",
+				prevEntry.Label, prevEntry.Slot, prevEntry.Offset, nextEntry.Slot, nextEntry.Offset))
+		}
+		// Type identifiers embed canonicalized AST IDs that can shift when
+		// unrelated types are added, so compare the types by their label and
+		// size instead.
+		prevType, nextType := prev.Types[prevEntry.Type], next.Types[nextEntry.Type]
+		if prevType.Label != nextType.Label || prevType.NumberOfBytes != nextType.NumberOfBytes {
+			changes = append(changes, fmt.Sprintf("%s: type changed from %s (%d bytes) to %s (%d bytes)",
+				prevEntry.Label, prevType.Label, prevType.NumberOfBytes, nextType.Label, nextType.NumberOfBytes))
+		}
+	}
+	return changes
+}
@@ -0,0 +1,208 @@
+package bindgen
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/solc"
+	"github.com/ethereum-optimism/optimism/op-service/testlog"
+)
+
+func testLayout(entries ...solc.StorageLayoutEntry) *solc.StorageLayout {
+	return &solc.StorageLayout{
+		Storage: entries,
+		Types: map[string]solc.StorageLayoutType{
+			"t_uint256": {Encoding: "inplace", Label: "uint256", NumberOfBytes: 32},
+			"t_uint64":  {Encoding: "inplace", Label: "uint64", NumberOfBytes: 8},
+		},
+	}
+}
+
+func TestStorageLayoutChanges(t *testing.T) {
+	a := solc.StorageLayoutEntry{Label: "a", Slot: 0, Type: "t_uint256"}
+	b := solc.StorageLayoutEntry{Label: "b", Slot: 1, Type: "t_uint256"}
+	c := solc.StorageLayoutEntry{Label: "c", Slot: 2, Type: "t_uint256"}
+
+	tests := []struct {
+		name    string
+		prev    *solc.StorageLayout
+		next    *solc.StorageLayout
+		changes []string
+	}{
+		{
+			name: "unchanged",
+			prev: testLayout(a, b),
+			next: testLayout(a, b),
+		},
+		{
+			name: "appended",
+			prev: testLayout(a, b),
+			next: testLayout(a, b, c),
+		},
+		{
+			name:    "removed",
+			prev:    testLayout(a, b),
+			next:    testLayout(a),
+			changes: []string{"b: removed from slot 1 offset 0"},
+		},
+		{
+			name: "inserted",
+			prev: testLayout(a, b),
+			next: testLayout(a, solc.StorageLayoutEntry{Label: "c", Slot: 1, Type: "t_uint256"},
+				solc.StorageLayoutEntry{Label: "b", Slot: 2, Type: "t_uint256"}),
+			changes: []string{"b: moved from slot 1 offset 0 to slot 2 offset 0"},
+		},
+		{
+			name:    "resized",
+			prev:    testLayout(a, b),
+			next:    testLayout(a, solc.StorageLayoutEntry{Label: "b", Slot: 1, Type: "t_uint64"}),
+			changes: []string{"b: type changed from uint256 (32 bytes) to uint64 (8 bytes)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.changes, storageLayoutChanges(tt.prev, tt.next))
+		})
+	}
+}
+
+func TestReadCommittedStorageLayout(t *testing.T) {
+	dir := t.TempDir()
+
+	layout, err := readCommittedStorageLayout(filepath.Join(dir, "missing_more.go"), "Missing")
+	require.NoError(t, err)
+	require.Nil(t, layout)
+
+	metadataFile := filepath.Join(dir, "foo_more.go")
+	contents := `const FooStorageLayoutJSON = "{\"storage\":[{\"astId\":1000,\"contract\":\"src/Foo.sol:Foo\",\"label\":\"x\",\"offset\":0,\"slot\":\"3\",\"type\":\"t_uint256\"}],\"types\":{}}"`
+	require.NoError(t, os.WriteFile(metadataFile, []byte(contents), 0o600))
+
+	layout, err = readCommittedStorageLayout(metadataFile, "Foo")
+	require.NoError(t, err)
+	require.Len(t, layout.Storage, 1)
+	require.Equal(t, "x", layout.Storage[0].Label)
+	require.Equal(t, uint(3), layout.Storage[0].Slot)
+}
+
+func TestMarshalStorageLayout(t *testing.T) {
+	a := solc.StorageLayoutEntry{AstId: 1, Contract: "src/Foo.sol:Foo", Label: "a", Slot: 0, Type: "t_uint64"}
+	b := solc.StorageLayoutEntry{AstId: 2, Contract: "src/Foo.sol:Foo", Label: "b", Slot: 0, Offset: 8, Type: "t_uint64"}
+	c := solc.StorageLayoutEntry{AstId: 3, Contract: "src/Foo.sol:Foo", Label: "c", Slot: 1, Type: "t_uint256"}
+
+	first, err := marshalStorageLayout(testLayout(a, b, c))
+	require.NoError(t, err)
+	second, err := marshalStorageLayout(testLayout(a, b, c))
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+
+	shuffled := testLayout(c, b, a)
+	reordered, err := marshalStorageLayout(shuffled)
+	require.NoError(t, err)
+	require.Equal(t, first, reordered)
+	require.Equal(t, "c", shuffled.Storage[0].Label, "input layout must not be modified")
+
+	empty, err := marshalStorageLayout(&solc.StorageLayout{})
+	require.NoError(t, err)
+	require.Equal(t, `{"storage":null,"types":{}}`, string(empty), "contracts without storage keep encoding it as null")
+}
+
+func TestQuoteStorageLayout(t *testing.T) {
+	labels := []string{`quote"d`, "new\nline", `back\slash`}
+	layout := testLayout()
+	for i, label := range labels {
+		layout.Storage = append(layout.Storage, solc.StorageLayoutEntry{Label: label, Slot: uint(i), Type: "t_mapping(t_uint64,t_uint256)"})
+	}
+	layout.Types["t_mapping(t_uint64,t_uint256)"] = solc.StorageLayoutType{Encoding: "mapping", Label: "mapping(uint64 => uint256)", NumberOfBytes: 32, Key: "t_uint64", Value: "t_uint256"}
+	ser, err := marshalStorageLayout(layout)
+	require.NoError(t, err)
+
+	quoted, err := quoteStorageLayout(ser)
+	require.NoError(t, err)
+	require.NotContains(t, quoted, "\n", "the literal must fit on one line")
+	require.Contains(t, quoted, `uint64 =\u003e uint256`, "HTML characters are escaped as in the committed metadata")
+
+	unquoted, err := strconv.Unquote(`"` + quoted + `"`)
+	require.NoError(t, err)
+	var decoded solc.StorageLayout
+	require.NoError(t, json.Unmarshal([]byte(unquoted), &decoded))
+	require.Len(t, decoded.Storage, len(labels))
+	for i, label := range labels {
+		require.Equal(t, label, decoded.Storage[i].Label)
+	}
+	require.Equal(t, "mapping(uint64 => uint256)", decoded.Types["t_mapping(t_uint64,t_uint256)"].Label)
+}
+
+func TestValidateStorageLayout(t *testing.T) {
+	valid := testLayout(
+		solc.StorageLayoutEntry{Label: "a", Slot: 0, Type: "t_uint256"},
+		solc.StorageLayoutEntry{Label: "b", Slot: 1, Type: "t_mapping(t_uint64,t_uint256)"},
+	)
+	valid.Types["t_mapping(t_uint64,t_uint256)"] = solc.StorageLayoutType{Encoding: "mapping", Label: "mapping(uint64 => uint256)", NumberOfBytes: 32, Key: "t_uint64", Value: "t_uint256"}
+	require.NoError(t, validateStorageLayout(valid))
+	require.NoError(t, validateStorageLayout(&solc.StorageLayout{}), "contracts without storage are valid")
+
+	invalid := testLayout(
+		solc.StorageLayoutEntry{Slot: 0, Type: "t_uint256"},
+		solc.StorageLayoutEntry{Label: "b", Slot: 1, Type: "t_uint128"},
+		solc.StorageLayoutEntry{Label: "c", Slot: 2, Type: "t_array(t_uint32)dyn_storage"},
+	)
+	invalid.Types["t_array(t_uint32)dyn_storage"] = solc.StorageLayoutType{Encoding: "dynamic_array", Label: "uint32[]", NumberOfBytes: 32, Base: "t_uint32"}
+	invalid.Types["t_bool"] = solc.StorageLayoutType{Encoding: "packed", Label: "bool"}
+	require.EqualError(t, validateStorageLayout(invalid), `type "t_array(t_uint32)dyn_storage" refers to unknown type "t_uint32"
+type "t_bool" has no size
+type "t_bool" has unknown encoding "packed"
+variable "b" has unknown type "t_uint128"
+variable in slot 0 has no label`)
+}
+
+func TestDecodeStorageLayout(t *testing.T) {
+	layout, err := decodeStorageLayout([]byte(`{"storage":[{"astId":1,"contract":"src/Foo.sol:Foo","label":"s","offset":0,"slot":"0","type":"t_struct(S)1_storage"}],` +
+		`"types":{"t_struct(S)1_storage":{"encoding":"inplace","label":"struct Foo.S","numberOfBytes":"32","members":[{"astId":2,"label":"a","offset":0,"slot":"0","type":"t_uint256"}]}}}`))
+	require.NoError(t, err, "struct members are allowed")
+	require.Equal(t, solc.StorageLayoutType{Encoding: "inplace", Label: "struct Foo.S", NumberOfBytes: 32}, layout.Types["t_struct(S)1_storage"])
+
+	layout, err = decodeStorageLayout([]byte(`{"storage":null,"types":null}`))
+	require.NoError(t, err)
+	require.Nil(t, layout.Storage)
+	require.Nil(t, layout.Types)
+
+	_, err = decodeStorageLayout([]byte(`{"storage":[{"slot":0}],"types":{}}`))
+	require.ErrorContains(t, err, "cannot decode storage layout")
+	_, err = decodeStorageLayout([]byte(`{"storage":[],"types":{},"extra":1}`))
+	require.ErrorContains(t, err, "cannot decode storage layout")
+	_, err = decodeStorageLayout([]byte(`{"storage":[],"types":{"t_uint256":{"encoding":"inplace","label":"uint256","numberOfBytes":"32","size":32}}}`))
+	require.ErrorContains(t, err, `unknown field "size"`)
+}
+
+func TestStorageLayoutFallback(t *testing.T) {
+	dir := t.TempDir()
+	g := &generator{logger: testlog.Logger(t, log.LvlInfo)}
+	withLayout, err := decodeArtifact([]byte(`{"storageLayout":{"storage":[],"types":{}}}`))
+	require.NoError(t, err)
+	withoutLayout, err := decodeArtifact([]byte(`{"abi":[]}`))
+	require.NoError(t, err)
+
+	layout, err := g.storageLayout("Foo", withLayout)
+	require.NoError(t, err)
+	require.Empty(t, layout.Storage)
+
+	_, err = g.storageLayout("Foo", withoutLayout)
+	require.ErrorContains(t, err, `forge artifact of "Foo" has no storage layout, build it with --extra-output storageLayout`)
+
+	g.StorageLayoutDir = dir
+	_, err = g.storageLayout("Foo", withoutLayout)
+	require.ErrorContains(t, err, filepath.Join(dir, "Foo.json")+" does not exist")
+
+	sidecar := `{"storage":[{"astId":3,"contract":"src/Foo.sol:Foo","label":"x","offset":0,"slot":"0","type":"t_uint256"}],"types":{"t_uint256":{"encoding":"inplace","label":"uint256","numberOfBytes":"32"}}}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Foo.json"), []byte(sidecar), 0o600))
+	layout, err = g.storageLayout("Foo", withoutLayout)
+	require.NoError(t, err)
+	require.Len(t, layout.Storage, 1)
+	require.Equal(t, "x", layout.Storage[0].Label)
+}
@@ -0,0 +1,96 @@
+package bindgen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// summary counts what happened to the contracts of a run. It is safe for
+// concurrent use.
+type summary struct {
+	generated    atomic.Int64
+	skipped      atomic.Int64
+	failed       atomic.Int64
+	sourceMaps   atomic.Int64
+	bytesWritten atomic.Int64
+}
+
+// summaryReport is the JSON encoding of a summary.
+type summaryReport struct {
+	Contracts    int64 `json:"contracts"`
+	Generated    int64 `json:"generated"`
+	Skipped      int64 `json:"skipped"`
+	Failed       int64 `json:"failed"`
+	SourceMaps   int64 `json:"sourceMaps"`
+	BytesWritten int64 `json:"bytesWritten"`
+}
+
+func (s *summary) report() summaryReport {
+	r := summaryReport{
+		Generated:    s.generated.Load(),
+		Skipped:      s.skipped.Load(),
+		Failed:       s.failed.Load(),
+		SourceMaps:   s.sourceMaps.Load(),
+		BytesWritten: s.bytesWritten.Load(),
+	}
+	r.Contracts = r.Generated + r.Skipped + r.Failed
+	return r
+}
+
+// logSummary logs the summary of the run, and writes it as JSON to the
+// Summary path if set.
+func (g *generator) logSummary() error {
+	r := g.summary.report()
+	g.logger.Info("Finished generating bindings", "contracts", r.Contracts, "generated", r.Generated,
+		"skipped", r.Skipped, "failed", r.Failed, "sourceMaps", r.SourceMaps, "bytesWritten", r.BytesWritten)
+	// The summary describes the run rather than being generated from the
+	// artifacts, so there is nothing to compare it with in check mode.
+	if g.Summary == "" || g.Check {
+		return nil
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling summary: %w", err)
+	}
+	return g.writeOutput(g.Summary, append(data, '\n'))
+}
+
+// failures collects the errors of the contracts that failed to generate when
+// KeepGoing is set. It is safe for concurrent use.
+type failures struct {
+	mu   sync.Mutex
+	errs map[string]error
+}
+
+func (f *failures) add(name string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.errs == nil {
+		f.errs = make(map[string]error)
+	}
+	f.errs[name] = err
+}
+
+// err returns an error listing the failure of every contract, sorted by
+// contract name, or nil when there are none.
+func (f *failures) err() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.errs) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(f.errs))
+	for name := range f.errs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	errs := make([]error, 0, len(names))
+	for _, name := range names {
+		errs = append(errs, fmt.Errorf("%s: %w", name, f.errs[name]))
+	}
+	return fmt.Errorf("failed to generate the bindings of %d contracts:\n%w", len(names), errors.Join(errs...))
+}
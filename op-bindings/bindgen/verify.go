@@ -0,0 +1,203 @@
+package bindgen
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// maxIPFSChunkSize is the size above which IPFS splits a file into several
+// blocks, which changes how its hash is computed.
+const maxIPFSChunkSize = 256 * 1024
+
+// checkCompilerVersion fails, or warns when CompilerVersionWarn is set, when
+// the forge artifact of a contract was compiled with a solc version outside of
+// the allowed versions. The version is empty when the artifact has no
+// compiler metadata. Raw artifacts are not compiled by solc and are not
+// checked.
+func (g *generator) checkCompilerVersion(name, version string, raw bool) error {
+	if len(g.solcVersions) == 0 || raw {
+		return nil
+	}
+	if compilerVersionAllowed(version, g.solcVersions) {
+		return nil
+	}
+	if g.CompilerVersionWarn {
+		g.logger.Warn("Contract compiled with a compiler version that is not allowed", "contract", name, "version", version)
+		return nil
+	}
+	if version == "" {
+		return fmt.Errorf("cannot check the compiler version of %q, the artifact has no compiler metadata, is the metadata extra output enabled?", name)
+	}
+	return fmt.Errorf("%q was compiled with solc %s, which is not one of the allowed versions %s", name, version, strings.Join(g.solcVersions, ", "))
+}
+
+// compilerVersionAllowed reports whether version, such as
+// 0.8.15+commit.e14f2714, is one of the allowed versions. An allowed version
+// matches with or without its build metadata.
+func compilerVersionAllowed(version string, allowed []string) bool {
+	if version == "" {
+		return false
+	}
+	for _, a := range allowed {
+		if version == a || strings.HasPrefix(version, a+"+") {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyMetadataHash logs a warning when the metadata hash that solc embeds at
+// the end of the deployed bytecode does not match the metadata stored in the
+// artifact, which happens when forge output is only partially rebuilt.
+func verifyMetadataHash(logger log.Logger, name, rawMetadata string, deployedBytecode []byte) {
+	if rawMetadata == "" {
+		logger.Warn("Cannot verify metadata hash, the artifact has no rawMetadata", "contract", name)
+		return
+	}
+	fields, err := bytecodeMetadata(deployedBytecode)
+	if err != nil {
+		logger.Warn("Cannot verify metadata hash", "contract", name, "err", err)
+		return
+	}
+	embedded, ok := fields["ipfs"]
+	if !ok {
+		logger.Warn("Cannot verify metadata hash, the deployed bytecode has no IPFS metadata hash", "contract", name)
+		return
+	}
+	computed, err := ipfsHash([]byte(rawMetadata))
+	if err != nil {
+		logger.Warn("Cannot verify metadata hash", "contract", name, "err", err)
+		return
+	}
+	if !bytes.Equal(embedded, computed) {
+		logger.Warn("Deployed bytecode metadata hash does not match the artifact metadata, the artifact may be stale",
+			"contract", name, "embedded", hexutil.Bytes(embedded), "computed", hexutil.Bytes(computed))
+	}
+}
+
+// ipfsHash returns the multihash of the CIDv0 that IPFS assigns to data when
+// it is added as a single-block file. This is the hash solc embeds in the
+// bytecode for its metadata.
+func ipfsHash(data []byte) ([]byte, error) {
+	if len(data) > maxIPFSChunkSize {
+		return nil, fmt.Errorf("metadata of %d bytes is too large to hash", len(data))
+	}
+
+	// UnixFS Data message: Type = File, Data = data, filesize = len(data)
+	unixfs := []byte{0x08, 0x02, 0x12}
+	unixfs = binary.AppendUvarint(unixfs, uint64(len(data)))
+	unixfs = append(unixfs, data...)
+	unixfs = append(unixfs, 0x18)
+	unixfs = binary.AppendUvarint(unixfs, uint64(len(data)))
+
+	// DAG-PB PBNode message without links: Data = unixfs
+	node := []byte{0x0a}
+	node = binary.AppendUvarint(node, uint64(len(unixfs)))
+	node = append(node, unixfs...)
+
+	digest := sha256.Sum256(node)
+	return append([]byte{0x12, 0x20}, digest[:]...), nil
+}
+
+// bytecodeMetadata decodes the CBOR encoded metadata that solc appends to the
+// end of the deployed bytecode. The last two bytes hold the length of the
+// CBOR data. Only the value types solc emits are supported, byte and text
+// strings are returned as bytes and booleans are skipped.
+func bytecodeMetadata(code []byte) (map[string][]byte, error) {
+	if len(code) < 2 {
+		return nil, errors.New("bytecode is too short to hold metadata")
+	}
+	length := int(binary.BigEndian.Uint16(code[len(code)-2:]))
+	if length+2 > len(code) {
+		return nil, fmt.Errorf("invalid metadata length %d", length)
+	}
+	r := &cborReader{data: code[len(code)-2-length : len(code)-2]}
+
+	major, count, err := r.header()
+	if err != nil {
+		return nil, err
+	}
+	if major != 5 {
+		return nil, fmt.Errorf("expected a CBOR map, got major type %d", major)
+	}
+	fields := make(map[string][]byte, count)
+	for i := uint64(0); i < count; i++ {
+		major, n, err := r.header()
+		if err != nil {
+			return nil, err
+		}
+		if major != 3 {
+			return nil, fmt.Errorf("expected a CBOR text key, got major type %d", major)
+		}
+		key, err := r.bytes(n)
+		if err != nil {
+			return nil, err
+		}
+
+		major, n, err = r.header()
+		if err != nil {
+			return nil, err
+		}
+		switch major {
+		case 2, 3:
+			value, err := r.bytes(n)
+			if err != nil {
+				return nil, err
+			}
+			fields[string(key)] = value
+		case 7:
+			// simple values such as the experimental flag carry no payload
+		default:
+			return nil, fmt.Errorf("unsupported CBOR major type %d for %q", major, key)
+		}
+	}
+	return fields, nil
+}
+
+// cborReader reads the subset of CBOR used by the solc metadata.
+type cborReader struct {
+	data []byte
+	pos  int
+}
+
+// header reads the initial byte of a data item and its argument.
+func (r *cborReader) header() (major byte, arg uint64, err error) {
+	if r.pos >= len(r.data) {
+		return 0, 0, errors.New("unexpected end of CBOR data")
+	}
+	b := r.data[r.pos]
+	r.pos++
+	major, info := b>>5, b&0x1f
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info <= 27:
+		size := 1 << (info - 24)
+		raw, err := r.bytes(uint64(size))
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, v := range raw {
+			arg = arg<<8 | uint64(v)
+		}
+		return major, arg, nil
+	default:
+		return 0, 0, fmt.Errorf("unsupported CBOR additional information %d", info)
+	}
+}
+
+func (r *cborReader) bytes(n uint64) ([]byte, error) {
+	if n > uint64(len(r.data)-r.pos) {
+		return nil, errors.New("unexpected end of CBOR data")
+	}
+	out := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return out, nil
+}
@@ -0,0 +1,71 @@
+package bindgen
+
+import (
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/op-service/testlog"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPFSHash(t *testing.T) {
+	// `ipfs add` of "hello world\n" yields QmT78zSuBmuS4z925WZfrqQ1qHaJ56DQaTfyMUF7F8ff5o
+	hash, err := ipfsHash([]byte("hello world\n"))
+	require.NoError(t, err)
+	require.Equal(t, common.FromHex("0x122046d44814b9c5af141c3aaab7c05dc5e844ead5f91f12858b021eba45768b4c0e"), hash)
+
+	_, err = ipfsHash(make([]byte, maxIPFSChunkSize+1))
+	require.Error(t, err)
+}
+
+func TestBytecodeMetadata(t *testing.T) {
+	hash := common.FromHex("0x122046d44814b9c5af141c3aaab7c05dc5e844ead5f91f12858b021eba45768b4c0e")
+
+	// {"ipfs": hash, "solc": 0.8.15} as emitted by solc, preceded by code.
+	code := common.FromHex("0x6080604052")
+	code = append(code, common.FromHex("0xa264697066735822")...)
+	code = append(code, hash...)
+	code = append(code, common.FromHex("0x64736f6c634300080f0033")...)
+
+	fields, err := bytecodeMetadata(code)
+	require.NoError(t, err)
+	require.Equal(t, hash, fields["ipfs"])
+	require.Equal(t, []byte{0, 8, 15}, fields["solc"])
+
+	_, err = bytecodeMetadata(common.FromHex("0x6080604052"))
+	require.Error(t, err)
+}
+
+func TestCheckCompilerVersion(t *testing.T) {
+	version := "0.8.19+commit.7dd6d404"
+
+	logger := testlog.Logger(t, log.LvlInfo)
+	logs := testlog.Capture(logger)
+	g := &generator{logger: logger}
+	require.NoError(t, g.checkCompilerVersion("Foo", version, false), "every version is allowed by default")
+
+	g.solcVersions = []string{"0.8.15", "0.8.19"}
+	require.NoError(t, g.checkCompilerVersion("Foo", version, false))
+	require.NoError(t, g.checkCompilerVersion("Vault", "", true))
+
+	g.solcVersions = []string{"0.8.15"}
+	require.ErrorContains(t, g.checkCompilerVersion("Foo", version, false), `"Foo" was compiled with solc 0.8.19+commit.7dd6d404, which is not one of the allowed versions 0.8.15`)
+	require.ErrorContains(t, g.checkCompilerVersion("Foo", "", false), "has no compiler metadata")
+
+	g.CompilerVersionWarn = true
+	require.NoError(t, g.checkCompilerVersion("Foo", version, false))
+	record := logs.FindLog(log.LvlWarn, "Contract compiled with a compiler version that is not allowed")
+	require.NotNil(t, record)
+	require.Equal(t, "0.8.19+commit.7dd6d404", record.GetContextValue("version"))
+}
+
+func TestCompilerVersionAllowed(t *testing.T) {
+	allowed := []string{"0.8.15", "0.8.19+commit.7dd6d404"}
+	require.True(t, compilerVersionAllowed("0.8.15", allowed))
+	require.True(t, compilerVersionAllowed("0.8.15+commit.e14f2714", allowed))
+	require.True(t, compilerVersionAllowed("0.8.19+commit.7dd6d404", allowed))
+	require.False(t, compilerVersionAllowed("0.8.19+commit.00000000", allowed))
+	require.False(t, compilerVersionAllowed("0.8.150", allowed))
+	require.False(t, compilerVersionAllowed("", allowed))
+}
@@ -22,4 +22,5 @@ func init() {
 
 	layouts["AddressManager"] = AddressManagerStorageLayout
 	deployedBytecodes["AddressManager"] = AddressManagerDeployedBin
+	creationBytecodes["AddressManager"] = AddressManagerBin
 }
@@ -22,4 +22,5 @@ func init() {
 
 	layouts["AlphabetVM"] = AlphabetVMStorageLayout
 	deployedBytecodes["AlphabetVM"] = AlphabetVMDeployedBin
+	creationBytecodes["AlphabetVM"] = AlphabetVMBin
 }
@@ -22,4 +22,5 @@ func init() {
 
 	layouts["BaseFeeVault"] = BaseFeeVaultStorageLayout
 	deployedBytecodes["BaseFeeVault"] = BaseFeeVaultDeployedBin
+	creationBytecodes["BaseFeeVault"] = BaseFeeVaultBin
 }
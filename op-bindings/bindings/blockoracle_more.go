@@ -22,4 +22,5 @@ func init() {
 
 	layouts["BlockOracle"] = BlockOracleStorageLayout
 	deployedBytecodes["BlockOracle"] = BlockOracleDeployedBin
+	creationBytecodes["BlockOracle"] = BlockOracleBin
 }
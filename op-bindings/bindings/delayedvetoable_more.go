@@ -22,4 +22,5 @@ func init() {
 
 	layouts["DelayedVetoable"] = DelayedVetoableStorageLayout
 	deployedBytecodes["DelayedVetoable"] = DelayedVetoableDeployedBin
+	creationBytecodes["DelayedVetoable"] = DelayedVetoableBin
 }
@@ -22,4 +22,5 @@ func init() {
 
 	layouts["DeployerWhitelist"] = DeployerWhitelistStorageLayout
 	deployedBytecodes["DeployerWhitelist"] = DeployerWhitelistDeployedBin
+	creationBytecodes["DeployerWhitelist"] = DeployerWhitelistBin
 }
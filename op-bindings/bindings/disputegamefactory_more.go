@@ -22,4 +22,5 @@ func init() {
 
 	layouts["DisputeGameFactory"] = DisputeGameFactoryStorageLayout
 	deployedBytecodes["DisputeGameFactory"] = DisputeGameFactoryDeployedBin
+	creationBytecodes["DisputeGameFactory"] = DisputeGameFactoryBin
 }
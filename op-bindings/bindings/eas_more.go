@@ -22,4 +22,5 @@ func init() {
 
 	layouts["EAS"] = EASStorageLayout
 	deployedBytecodes["EAS"] = EASDeployedBin
+	creationBytecodes["EAS"] = EASBin
 }
@@ -22,4 +22,5 @@ func init() {
 
 	layouts["ERC20"] = ERC20StorageLayout
 	deployedBytecodes["ERC20"] = ERC20DeployedBin
+	creationBytecodes["ERC20"] = ERC20Bin
 }
@@ -22,4 +22,5 @@ func init() {
 
 	layouts["FaultDisputeGame"] = FaultDisputeGameStorageLayout
 	deployedBytecodes["FaultDisputeGame"] = FaultDisputeGameDeployedBin
+	creationBytecodes["FaultDisputeGame"] = FaultDisputeGameBin
 }
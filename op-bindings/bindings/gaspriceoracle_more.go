@@ -22,4 +22,5 @@ func init() {
 
 	layouts["GasPriceOracle"] = GasPriceOracleStorageLayout
 	deployedBytecodes["GasPriceOracle"] = GasPriceOracleDeployedBin
+	creationBytecodes["GasPriceOracle"] = GasPriceOracleBin
 }
@@ -22,4 +22,5 @@ func init() {
 
 	layouts["GovernanceToken"] = GovernanceTokenStorageLayout
 	deployedBytecodes["GovernanceToken"] = GovernanceTokenDeployedBin
+	creationBytecodes["GovernanceToken"] = GovernanceTokenBin
 }
@@ -22,4 +22,5 @@ func init() {
 
 	layouts["L1Block"] = L1BlockStorageLayout
 	deployedBytecodes["L1Block"] = L1BlockDeployedBin
+	creationBytecodes["L1Block"] = L1BlockBin
 }
@@ -22,4 +22,5 @@ func init() {
 
 	layouts["L1BlockNumber"] = L1BlockNumberStorageLayout
 	deployedBytecodes["L1BlockNumber"] = L1BlockNumberDeployedBin
+	creationBytecodes["L1BlockNumber"] = L1BlockNumberBin
 }
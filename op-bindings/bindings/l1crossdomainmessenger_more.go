@@ -22,4 +22,5 @@ func init() {
 
 	layouts["L1CrossDomainMessenger"] = L1CrossDomainMessengerStorageLayout
 	deployedBytecodes["L1CrossDomainMessenger"] = L1CrossDomainMessengerDeployedBin
+	creationBytecodes["L1CrossDomainMessenger"] = L1CrossDomainMessengerBin
 }
@@ -22,4 +22,5 @@ func init() {
 
 	layouts["L1ERC721Bridge"] = L1ERC721BridgeStorageLayout
 	deployedBytecodes["L1ERC721Bridge"] = L1ERC721BridgeDeployedBin
+	creationBytecodes["L1ERC721Bridge"] = L1ERC721BridgeBin
 }
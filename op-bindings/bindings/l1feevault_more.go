@@ -22,4 +22,5 @@ func init() {
 
 	layouts["L1FeeVault"] = L1FeeVaultStorageLayout
 	deployedBytecodes["L1FeeVault"] = L1FeeVaultDeployedBin
+	creationBytecodes["L1FeeVault"] = L1FeeVaultBin
 }
@@ -22,4 +22,5 @@ func init() {
 
 	layouts["L1StandardBridge"] = L1StandardBridgeStorageLayout
 	deployedBytecodes["L1StandardBridge"] = L1StandardBridgeDeployedBin
+	creationBytecodes["L1StandardBridge"] = L1StandardBridgeBin
 }
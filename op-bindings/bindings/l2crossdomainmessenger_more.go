@@ -22,4 +22,5 @@ func init() {
 
 	layouts["L2CrossDomainMessenger"] = L2CrossDomainMessengerStorageLayout
 	deployedBytecodes["L2CrossDomainMessenger"] = L2CrossDomainMessengerDeployedBin
+	creationBytecodes["L2CrossDomainMessenger"] = L2CrossDomainMessengerBin
 }
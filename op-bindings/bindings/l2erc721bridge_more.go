@@ -22,4 +22,5 @@ func init() {
 
 	layouts["L2ERC721Bridge"] = L2ERC721BridgeStorageLayout
 	deployedBytecodes["L2ERC721Bridge"] = L2ERC721BridgeDeployedBin
+	creationBytecodes["L2ERC721Bridge"] = L2ERC721BridgeBin
 }
@@ -22,4 +22,5 @@ func init() {
 
 	layouts["L2OutputOracle"] = L2OutputOracleStorageLayout
 	deployedBytecodes["L2OutputOracle"] = L2OutputOracleDeployedBin
+	creationBytecodes["L2OutputOracle"] = L2OutputOracleBin
 }
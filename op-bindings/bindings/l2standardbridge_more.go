@@ -22,4 +22,5 @@ func init() {
 
 	layouts["L2StandardBridge"] = L2StandardBridgeStorageLayout
 	deployedBytecodes["L2StandardBridge"] = L2StandardBridgeDeployedBin
+	creationBytecodes["L2StandardBridge"] = L2StandardBridgeBin
 }
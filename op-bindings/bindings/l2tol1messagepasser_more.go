@@ -22,4 +22,5 @@ func init() {
 
 	layouts["L2ToL1MessagePasser"] = L2ToL1MessagePasserStorageLayout
 	deployedBytecodes["L2ToL1MessagePasser"] = L2ToL1MessagePasserDeployedBin
+	creationBytecodes["L2ToL1MessagePasser"] = L2ToL1MessagePasserBin
 }
@@ -22,4 +22,5 @@ func init() {
 
 	layouts["LegacyERC20ETH"] = LegacyERC20ETHStorageLayout
 	deployedBytecodes["LegacyERC20ETH"] = LegacyERC20ETHDeployedBin
+	creationBytecodes["LegacyERC20ETH"] = LegacyERC20ETHBin
 }
@@ -22,4 +22,5 @@ func init() {
 
 	layouts["LegacyMessagePasser"] = LegacyMessagePasserStorageLayout
 	deployedBytecodes["LegacyMessagePasser"] = LegacyMessagePasserDeployedBin
+	creationBytecodes["LegacyMessagePasser"] = LegacyMessagePasserBin
 }
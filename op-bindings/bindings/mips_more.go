@@ -24,4 +24,5 @@ func init() {
 
 	layouts["MIPS"] = MIPSStorageLayout
 	deployedBytecodes["MIPS"] = MIPSDeployedBin
+	creationBytecodes["MIPS"] = MIPSBin
 }
@@ -22,4 +22,5 @@ func init() {
 
 	layouts["OptimismMintableERC20"] = OptimismMintableERC20StorageLayout
 	deployedBytecodes["OptimismMintableERC20"] = OptimismMintableERC20DeployedBin
+	creationBytecodes["OptimismMintableERC20"] = OptimismMintableERC20Bin
 }
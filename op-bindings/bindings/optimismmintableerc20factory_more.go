@@ -22,4 +22,5 @@ func init() {
 
 	layouts["OptimismMintableERC20Factory"] = OptimismMintableERC20FactoryStorageLayout
 	deployedBytecodes["OptimismMintableERC20Factory"] = OptimismMintableERC20FactoryDeployedBin
+	creationBytecodes["OptimismMintableERC20Factory"] = OptimismMintableERC20FactoryBin
 }
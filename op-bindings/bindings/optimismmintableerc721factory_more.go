@@ -22,4 +22,5 @@ func init() {
 
 	layouts["OptimismMintableERC721Factory"] = OptimismMintableERC721FactoryStorageLayout
 	deployedBytecodes["OptimismMintableERC721Factory"] = OptimismMintableERC721FactoryDeployedBin
+	creationBytecodes["OptimismMintableERC721Factory"] = OptimismMintableERC721FactoryBin
 }
@@ -22,4 +22,5 @@ func init() {
 
 	layouts["OptimismPortal"] = OptimismPortalStorageLayout
 	deployedBytecodes["OptimismPortal"] = OptimismPortalDeployedBin
+	creationBytecodes["OptimismPortal"] = OptimismPortalBin
 }
@@ -24,4 +24,5 @@ func init() {
 
 	layouts["PreimageOracle"] = PreimageOracleStorageLayout
 	deployedBytecodes["PreimageOracle"] = PreimageOracleDeployedBin
+	creationBytecodes["PreimageOracle"] = PreimageOracleBin
 }
@@ -22,4 +22,5 @@ func init() {
 
 	layouts["ProtocolVersions"] = ProtocolVersionsStorageLayout
 	deployedBytecodes["ProtocolVersions"] = ProtocolVersionsDeployedBin
+	creationBytecodes["ProtocolVersions"] = ProtocolVersionsBin
 }
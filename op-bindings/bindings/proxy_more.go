@@ -22,4 +22,5 @@ func init() {
 
 	layouts["Proxy"] = ProxyStorageLayout
 	deployedBytecodes["Proxy"] = ProxyDeployedBin
+	creationBytecodes["Proxy"] = ProxyBin
 }
@@ -22,4 +22,5 @@ func init() {
 
 	layouts["ProxyAdmin"] = ProxyAdminStorageLayout
 	deployedBytecodes["ProxyAdmin"] = ProxyAdminDeployedBin
+	creationBytecodes["ProxyAdmin"] = ProxyAdminBin
 }
@@ -1,59 +1,69 @@
 package bindings
 
 import (
-	"fmt"
-	"strings"
-
+	"github.com/ethereum-optimism/optimism/op-bindings/registry"
 	"github.com/ethereum-optimism/optimism/op-bindings/solc"
-	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 )
 
+// contracts holds the metadata of the contracts of this package, which the
+// maps below populate.
+var contracts = registry.New()
+
 // layouts respresents the set of storage layouts. It is populated in an init function.
-var layouts = make(map[string]*solc.StorageLayout)
+var layouts = contracts.Layouts
 
 // deployedBytecodes represents the set of deployed bytecodes. It is populated
 // in an init function.
-var deployedBytecodes = make(map[string]string)
+var deployedBytecodes = contracts.DeployedBytecodes
+
+// creationBytecodes represents the set of creation bytecodes of the contracts
+// that can be deployed. It is populated in an init function.
+var creationBytecodes = contracts.CreationBytecodes
+
+// abis represents the set of ABIs of the contracts whose ABI is registered in
+// the metadata. It is populated in an init function.
+var abis = contracts.ABIs
+
+// compilerSettings represents the set of compiler settings the contracts were
+// built with, for the contracts whose settings are added to the metadata. It
+// is populated in an init function.
+var compilerSettings = contracts.Settings
+
+// CompilerSettings is the compiler configuration a contract was built with.
+type CompilerSettings = registry.CompilerSettings
+
+// deployedSourceMaps represents the set of gzip compressed deployed source maps
+// that are generated as separate files. It is populated in an init function.
+var deployedSourceMaps = contracts.DeployedSourceMaps
 
 // GetStorageLayout returns the storage layout of a contract by name.
 func GetStorageLayout(name string) (*solc.StorageLayout, error) {
-	layout := layouts[name]
-	if layout == nil {
-		return nil, fmt.Errorf("%s: storage layout not found", name)
-	}
-	return layout, nil
+	return contracts.StorageLayout(name)
 }
 
 // GetDeployedBytecode returns the deployed bytecode of a contract by name.
 func GetDeployedBytecode(name string) ([]byte, error) {
-	bc := deployedBytecodes[name]
-	if bc == "" {
-		return nil, fmt.Errorf("%s: deployed bytecode not found", name)
-	}
+	return contracts.DeployedBytecode(name)
+}
 
-	if !isHex(bc) {
-		return nil, fmt.Errorf("%s: invalid deployed bytecode", name)
-	}
+// GetCreationBytecode returns the creation bytecode of a contract by name.
+func GetCreationBytecode(name string) ([]byte, error) {
+	return contracts.CreationBytecode(name)
+}
 
-	return common.FromHex(bc), nil
+// GetABI returns the parsed ABI of a contract by name.
+func GetABI(name string) (*abi.ABI, error) {
+	return contracts.ABI(name)
 }
 
-// isHexCharacter returns bool of c being a valid hexadecimal.
-func isHexCharacter(c byte) bool {
-	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+// GetCompilerSettings returns the compiler settings of a contract by name.
+func GetCompilerSettings(name string) (CompilerSettings, error) {
+	return contracts.CompilerSettings(name)
 }
 
-// isHex validates whether each byte is valid hexadecimal string.
-func isHex(str string) bool {
-	if len(str)%2 != 0 {
-		return false
-	}
-	str = strings.TrimPrefix(str, "0x")
-
-	for _, c := range []byte(str) {
-		if !isHexCharacter(c) {
-			return false
-		}
-	}
-	return true
+// GetDeployedSourceMap returns the deployed source map of a contract by name,
+// for contracts whose source map is generated as a separate file.
+func GetDeployedSourceMap(name string) (string, error) {
+	return contracts.DeployedSourceMap(name)
 }
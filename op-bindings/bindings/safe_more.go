@@ -22,4 +22,5 @@ func init() {
 
 	layouts["Safe"] = SafeStorageLayout
 	deployedBytecodes["Safe"] = SafeDeployedBin
+	creationBytecodes["Safe"] = SafeBin
 }
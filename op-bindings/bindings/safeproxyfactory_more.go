@@ -22,4 +22,5 @@ func init() {
 
 	layouts["SafeProxyFactory"] = SafeProxyFactoryStorageLayout
 	deployedBytecodes["SafeProxyFactory"] = SafeProxyFactoryDeployedBin
+	creationBytecodes["SafeProxyFactory"] = SafeProxyFactoryBin
 }
@@ -22,4 +22,5 @@ func init() {
 
 	layouts["SchemaRegistry"] = SchemaRegistryStorageLayout
 	deployedBytecodes["SchemaRegistry"] = SchemaRegistryDeployedBin
+	creationBytecodes["SchemaRegistry"] = SchemaRegistryBin
 }
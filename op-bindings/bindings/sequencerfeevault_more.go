@@ -22,4 +22,5 @@ func init() {
 
 	layouts["SequencerFeeVault"] = SequencerFeeVaultStorageLayout
 	deployedBytecodes["SequencerFeeVault"] = SequencerFeeVaultDeployedBin
+	creationBytecodes["SequencerFeeVault"] = SequencerFeeVaultBin
 }
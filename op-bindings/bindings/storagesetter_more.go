@@ -22,4 +22,5 @@ func init() {
 
 	layouts["StorageSetter"] = StorageSetterStorageLayout
 	deployedBytecodes["StorageSetter"] = StorageSetterDeployedBin
+	creationBytecodes["StorageSetter"] = StorageSetterBin
 }
@@ -22,4 +22,5 @@ func init() {
 
 	layouts["SystemConfig"] = SystemConfigStorageLayout
 	deployedBytecodes["SystemConfig"] = SystemConfigDeployedBin
+	creationBytecodes["SystemConfig"] = SystemConfigBin
 }
@@ -22,4 +22,5 @@ func init() {
 
 	layouts["WETH9"] = WETH9StorageLayout
 	deployedBytecodes["WETH9"] = WETH9DeployedBin
+	creationBytecodes["WETH9"] = WETH9Bin
 }
@@ -0,0 +1,248 @@
+package foundry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/op-service/retry"
+)
+
+// Reads of forge artifacts are retried since the artifacts directory may be on
+// a network filesystem that occasionally fails with transient errors.
+const (
+	artifactReadAttempts = 3
+	artifactReadDelay    = 100 * time.Millisecond
+)
+
+// DefaultCompilerVersionPattern matches the compiler version that forge
+// appends to the artifact name when a contract is compiled with several solc
+// versions. It only matches at the end of the name, so that contract names
+// with version-like parts are left alone.
+const DefaultCompilerVersionPattern = `\.\d+\.\d+\.\d+$`
+
+// ParseContractID splits a contract identifier into the source path and the
+// contract name. Identifiers are either a plain contract name, such as "Foo",
+// or a fully-qualified identifier, such as "src/L1/Foo.sol:Foo". The source
+// path is empty for plain names.
+func ParseContractID(id string) (sourcePath, name string) {
+	if i := strings.LastIndex(id, ":"); i >= 0 {
+		return id[:i], id[i+1:]
+	}
+	return "", id
+}
+
+// ArtifactsDir is a forge artifacts directory along with the paths of the
+// artifacts it holds.
+type ArtifactsDir struct {
+	dir string
+	// paths holds the paths of every artifact keyed by contract name. If some
+	// contracts have the same name then the path to their artifact depends on
+	// their full import path, so a name can map to several artifacts.
+	paths map[string][]string
+}
+
+// ScanArtifacts walks the forge artifacts directory and finds the artifact of
+// every contract, named after the artifact file with the part that matches
+// versionRe removed.
+// A missing or empty directory is an error, since it usually means that the
+// contracts have not been built.
+func ScanArtifacts(dir string, versionRe *regexp.Regexp) (*ArtifactsDir, error) {
+	info, err := os.Stat(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("forge artifacts directory %s does not exist, did you run forge build?", dir)
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading forge artifacts directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("forge artifacts path %s is neither a directory nor a .zip, .tar.gz or .tgz archive", dir)
+	}
+
+	// Walk visits files in lexical order, so the paths are sorted.
+	paths := make(map[string][]string)
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if strings.HasSuffix(path, ".json") {
+			base := filepath.Base(path)
+			name := strings.TrimSuffix(base, ".json")
+
+			// remove the compiler version from the name
+			sanitized := versionRe.ReplaceAllString(name, "")
+			paths[sanitized] = append(paths[sanitized], path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no forge artifacts found in %s, did you run forge build?", dir)
+	}
+	return &ArtifactsDir{dir: dir, paths: paths}, nil
+}
+
+// Resolve returns the path to the artifact of a contract. Fully-qualified
+// identifiers are matched against the compilation target of every artifact
+// with the same contract name. For plain names the standard
+// <name>.sol/<name>.json location is preferred, otherwise the artifact found
+// while scanning is used as long as it is unambiguous.
+// When other contracts share the name of the standard artifact, it is still
+// returned along with a description of the artifacts that were ignored.
+func (d *ArtifactsDir) Resolve(id string) (artifactPath string, ignored []string, err error) {
+	sourcePath, name := ParseContractID(id)
+	candidates := d.paths[name]
+
+	if sourcePath != "" {
+		for _, candidate := range candidates {
+			candidateSource, err := artifactSourcePath(candidate)
+			if err != nil {
+				return "", nil, err
+			}
+			if candidateSource == sourcePath {
+				return candidate, nil, nil
+			}
+		}
+		return "", nil, fmt.Errorf("cannot find forge-artifact of %q", id)
+	}
+
+	chosen := path.Join(d.dir, name+".sol", name+".json")
+	_, err = os.Stat(chosen)
+	standard := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", nil, fmt.Errorf("error reading forge artifact of %q: %w", name, err)
+	}
+	if !standard {
+		if len(candidates) == 0 {
+			return "", nil, fmt.Errorf("cannot find forge-artifact of %q", name)
+		}
+		chosen = candidates[0]
+	}
+	if len(candidates) < 2 {
+		return chosen, nil, nil
+	}
+
+	// Several artifacts share the sanitized name. They may be the same
+	// contract built with different compiler versions, which is fine, or
+	// different contracts, in which case one of them would silently be used.
+	sources := make(map[string]string, len(candidates))
+	for _, candidate := range candidates {
+		source, err := artifactSourcePath(candidate)
+		if err != nil {
+			return "", nil, err
+		}
+		sources[candidate] = source
+	}
+	chosenSource, ok := sources[chosen]
+	if !ok {
+		if chosenSource, err = artifactSourcePath(chosen); err != nil {
+			return "", nil, err
+		}
+	}
+	var conflicts []string
+	for _, candidate := range candidates {
+		if sources[candidate] != chosenSource {
+			conflicts = append(conflicts, fmt.Sprintf("%s (%s:%s)", candidate, sources[candidate], name))
+		}
+	}
+	if len(conflicts) > 0 && !standard {
+		return "", nil, fmt.Errorf("contract name %q is ambiguous, use a fully-qualified identifier to pick one of %s (%s:%s), %s",
+			name, chosen, chosenSource, name, strings.Join(conflicts, ", "))
+	}
+	return chosen, conflicts, nil
+}
+
+// ResolveInFile returns the path to the artifact of the contract name defined
+// in the Solidity file, relative to the source directory. The
+// <file>/<name>.json artifact is preferred, otherwise one compiled with a
+// specific compiler version is used.
+func (d *ArtifactsDir) ResolveInFile(name, file string) (string, error) {
+	dir := filepath.Join(d.dir, file)
+	chosen := filepath.Join(dir, name+".json")
+	_, err := os.Stat(chosen)
+	if err == nil {
+		return chosen, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("error reading forge artifact of %q: %w", name, err)
+	}
+	for _, candidate := range d.paths[name] {
+		if filepath.Dir(candidate) == dir {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("cannot find forge-artifact of %q in %s", name, dir)
+}
+
+// ReadArtifactFile reads the forge artifact at artifactPath, retrying
+// transient errors. A missing artifact is reported immediately.
+func ReadArtifactFile(ctx context.Context, artifactPath string) ([]byte, error) {
+	var notExist error
+	data, err := retry.Do(ctx, artifactReadAttempts, retry.Fixed(artifactReadDelay), func() ([]byte, error) {
+		data, err := os.ReadFile(artifactPath)
+		if errors.Is(err, os.ErrNotExist) {
+			notExist = err
+			return nil, nil
+		}
+		return data, err
+	})
+	if notExist != nil {
+		return nil, notExist
+	}
+	return data, err
+}
+
+// artifactSourcePath returns the path of the source file the forge artifact
+// at artifactPath was compiled from.
+func artifactSourcePath(artifactPath string) (string, error) {
+	data, err := ReadArtifactFile(context.Background(), artifactPath)
+	if err != nil {
+		return "", fmt.Errorf("error reading forge artifact %s: %w", artifactPath, err)
+	}
+	var artifact struct {
+		Metadata struct {
+			Settings struct {
+				CompilationTarget map[string]string `json:"compilationTarget"`
+			} `json:"settings"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &artifact); err != nil {
+		return "", fmt.Errorf("failed to parse forge artifact %s: %w", artifactPath, err)
+	}
+	for sourcePath := range artifact.Metadata.Settings.CompilationTarget {
+		return sourcePath, nil
+	}
+	return "", fmt.Errorf("forge artifact %s has no compilation target, is the metadata extra output enabled?", artifactPath)
+}
+
+// LoadForgeArtifact loads the forge artifact of a contract from the forge
+// artifacts directory. The contract is resolved with Resolve, so a
+// fully-qualified identifier such as "src/L1/Foo.sol:Foo" picks between
+// contracts that share a name. The directory is scanned on every call.
+func LoadForgeArtifact(artifactsDir, contractName string) (Artifact, error) {
+	dir, err := ScanArtifacts(artifactsDir, regexp.MustCompile(DefaultCompilerVersionPattern))
+	if err != nil {
+		return Artifact{}, fmt.Errorf("error scanning forge artifacts: %w", err)
+	}
+	artifactPath, _, err := dir.Resolve(contractName)
+	if err != nil {
+		return Artifact{}, err
+	}
+	data, err := ReadArtifactFile(context.Background(), artifactPath)
+	if err != nil {
+		return Artifact{}, fmt.Errorf("error reading forge artifact of %q: %w", contractName, err)
+	}
+	var artifact Artifact
+	if err := json.Unmarshal(data, &artifact); err != nil {
+		return Artifact{}, fmt.Errorf("failed to parse forge artifact of %q: %w", contractName, err)
+	}
+	return artifact, nil
+}
@@ -0,0 +1,196 @@
+package foundry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimism/op-service/retry"
+)
+
+// writeTestArtifact writes a minimal forge artifact compiled from sourcePath
+// to path, relative to dir.
+func writeTestArtifact(t *testing.T, dir, path, sourcePath, name string) {
+	t.Helper()
+	artifact := fmt.Sprintf(`{"abi":[],"metadata":{"settings":{"compilationTarget":{%q:%q}}}}`, sourcePath, name)
+	full := filepath.Join(dir, path)
+	require.NoError(t, os.MkdirAll(filepath.Dir(full), 0o700))
+	require.NoError(t, os.WriteFile(full, []byte(artifact), 0o600))
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	writeTestArtifact(t, dir, "Foo.sol/Foo.json", "src/Foo.sol", "Foo")
+	writeTestArtifact(t, dir, "Bar.sol/Bar.0.8.15.json", "src/Bar.sol", "Bar")
+	writeTestArtifact(t, dir, "Bar.sol/Bar.0.8.19.json", "src/Bar.sol", "Bar")
+	writeTestArtifact(t, dir, "A/Qux.sol/Qux.json", "src/A/Qux.sol", "Qux")
+	writeTestArtifact(t, dir, "B/Qux.sol/Qux.json", "src/B/Qux.sol", "Qux")
+	writeTestArtifact(t, dir, "Baz.sol/Baz.json", "src/Baz.sol", "Baz")
+	writeTestArtifact(t, dir, "Baz.sol/Baz.1.2.3.json", "src/Other.sol", "Baz")
+
+	artifacts, err := ScanArtifacts(dir, regexp.MustCompile(DefaultCompilerVersionPattern))
+	require.NoError(t, err)
+
+	tests := []struct {
+		id       string
+		artifact string
+		ignored  []string
+		err      string
+	}{
+		{id: "Foo", artifact: "Foo.sol/Foo.json"},
+		{id: "src/Foo.sol:Foo", artifact: "Foo.sol/Foo.json"},
+		{id: "Bar", artifact: "Bar.sol/Bar.0.8.15.json"},
+		{id: "src/B/Qux.sol:Qux", artifact: "B/Qux.sol/Qux.json"},
+		{id: "Qux", err: `contract name "Qux" is ambiguous`},
+		{id: "src/C/Qux.sol:Qux", err: `cannot find forge-artifact of "src/C/Qux.sol:Qux"`},
+		{id: "Missing", err: `cannot find forge-artifact of "Missing"`},
+		{id: "Baz", artifact: "Baz.sol/Baz.json", ignored: []string{filepath.Join(dir, "Baz.sol/Baz.1.2.3.json") + " (src/Other.sol:Baz)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			artifactPath, ignored, err := artifacts.Resolve(tt.id)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, filepath.Join(dir, tt.artifact), artifactPath)
+			require.Equal(t, tt.ignored, ignored)
+		})
+	}
+}
+
+func TestResolveInFile(t *testing.T) {
+	dir := t.TempDir()
+	writeTestArtifact(t, dir, "Types.sol/Withdrawal.json", "src/Types.sol", "Withdrawal")
+	writeTestArtifact(t, dir, "Types.sol/Output.0.8.15.json", "src/Types.sol", "Output")
+	writeTestArtifact(t, dir, "Other.sol/Output.json", "src/Other.sol", "Output")
+
+	artifacts, err := ScanArtifacts(dir, regexp.MustCompile(DefaultCompilerVersionPattern))
+	require.NoError(t, err)
+
+	artifactPath, err := artifacts.ResolveInFile("Withdrawal", "Types.sol")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "Types.sol/Withdrawal.json"), artifactPath)
+
+	artifactPath, err = artifacts.ResolveInFile("Output", "Types.sol")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "Types.sol/Output.0.8.15.json"), artifactPath)
+
+	_, err = artifacts.ResolveInFile("Withdrawal", "Other.sol")
+	require.ErrorContains(t, err, `cannot find forge-artifact of "Withdrawal" in `+filepath.Join(dir, "Other.sol"))
+}
+
+func TestReadArtifactFile(t *testing.T) {
+	dir := t.TempDir()
+	writeTestArtifact(t, dir, "Foo.sol/Foo.json", "src/Foo.sol", "Foo")
+	ctx := context.Background()
+
+	data, err := ReadArtifactFile(ctx, filepath.Join(dir, "Foo.sol/Foo.json"))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "src/Foo.sol")
+
+	start := time.Now()
+	_, err = ReadArtifactFile(ctx, filepath.Join(dir, "Missing.sol/Missing.json"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.Less(t, time.Since(start), artifactReadDelay, "missing artifacts must not be retried")
+
+	// Reading a directory fails with an error that is not ErrNotExist, which
+	// stands in for a transient error.
+	_, err = ReadArtifactFile(ctx, filepath.Join(dir, "Foo.sol"))
+	var failed *retry.ErrFailedPermanently
+	require.ErrorAs(t, err, &failed)
+	require.NotErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestScanArtifactsRequiresArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	versionRe := regexp.MustCompile(DefaultCompilerVersionPattern)
+
+	_, err := ScanArtifacts(filepath.Join(dir, "missing"), versionRe)
+	require.ErrorContains(t, err, "does not exist, did you run forge build?")
+
+	_, err = ScanArtifacts(dir, versionRe)
+	require.ErrorContains(t, err, "no forge artifacts found in "+dir)
+
+	file := filepath.Join(dir, "artifacts.json")
+	require.NoError(t, os.WriteFile(file, []byte("[]"), 0o600))
+	_, err = ScanArtifacts(file, versionRe)
+	require.ErrorContains(t, err, "is neither a directory nor")
+}
+
+func TestScanArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	for _, path := range []string{
+		"Foo.sol/Foo.json",
+		"Foo.sol/Foo.0.8.15.json",
+		"MyToken.sol/MyToken.1.2.3Helper.json",
+		"MyToken.sol/MyToken.1.2.3Helper.0.8.19.json",
+		"Bar.sol/Bar-v2.json",
+	} {
+		writeTestArtifact(t, dir, path, "src/"+filepath.Dir(path), "")
+	}
+
+	tests := []struct {
+		pattern  string
+		expected map[string][]string
+	}{
+		{
+			pattern: DefaultCompilerVersionPattern,
+			expected: map[string][]string{
+				"Foo":                 {"Foo.sol/Foo.0.8.15.json", "Foo.sol/Foo.json"},
+				"MyToken.1.2.3Helper": {"MyToken.sol/MyToken.1.2.3Helper.0.8.19.json", "MyToken.sol/MyToken.1.2.3Helper.json"},
+				"Bar-v2":              {"Bar.sol/Bar-v2.json"},
+			},
+		},
+		{
+			pattern: `-v\d+$`,
+			expected: map[string][]string{
+				"Foo":                        {"Foo.sol/Foo.json"},
+				"Foo.0.8.15":                 {"Foo.sol/Foo.0.8.15.json"},
+				"MyToken.1.2.3Helper":        {"MyToken.sol/MyToken.1.2.3Helper.json"},
+				"MyToken.1.2.3Helper.0.8.19": {"MyToken.sol/MyToken.1.2.3Helper.0.8.19.json"},
+				"Bar":                        {"Bar.sol/Bar-v2.json"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			artifacts, err := ScanArtifacts(dir, regexp.MustCompile(tt.pattern))
+			require.NoError(t, err)
+			expected := make(map[string][]string, len(tt.expected))
+			for name, paths := range tt.expected {
+				for _, path := range paths {
+					expected[name] = append(expected[name], filepath.Join(dir, path))
+				}
+			}
+			require.Equal(t, expected, artifacts.paths)
+		})
+	}
+}
+
+func TestLoadForgeArtifact(t *testing.T) {
+	dir := t.TempDir()
+	writeTestArtifact(t, dir, "Foo.sol/Foo.json", "src/Foo.sol", "Foo")
+	writeTestArtifact(t, dir, "Lib.sol/Bar.0.8.15.json", "src/Lib.sol", "Bar")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Lib.sol/Bar.0.8.15.json"), []byte(`{"abi":[],"deployedBytecode":{"object":"0x6002"}}`), 0o600))
+
+	artifact, err := LoadForgeArtifact(dir, "Foo")
+	require.NoError(t, err)
+	require.Empty(t, artifact.DeployedBytecode.Object)
+
+	artifact, err = LoadForgeArtifact(dir, "Bar")
+	require.NoError(t, err)
+	require.Equal(t, hexutil.Bytes{0x60, 0x02}, artifact.DeployedBytecode.Object)
+
+	_, err = LoadForgeArtifact(dir, "Missing")
+	require.ErrorContains(t, err, `cannot find forge-artifact of "Missing"`)
+}
@@ -1,229 +1,72 @@
 package main
 
 import (
-	"encoding/json"
-	"errors"
 	"flag"
-	"log"
 	"os"
-	"os/exec"
-	"path"
-	"path/filepath"
-	"regexp"
-	"strings"
-	"text/template"
+	"runtime"
 
-	"github.com/ethereum-optimism/optimism/op-bindings/ast"
-	"github.com/ethereum-optimism/optimism/op-bindings/foundry"
-)
-
-type flags struct {
-	ForgeArtifacts string
-	Contracts      string
-	SourceMaps     string
-	OutDir         string
-	Package        string
-	MonorepoBase   string
-}
+	"github.com/ethereum/go-ethereum/log"
 
-type data struct {
-	Name              string
-	StorageLayout     string
-	DeployedBin       string
-	Package           string
-	DeployedSourceMap string
-}
+	"github.com/ethereum-optimism/optimism/op-bindings/bindgen"
+	oplog "github.com/ethereum-optimism/optimism/op-service/log"
+)
 
 func main() {
-	var f flags
-	flag.StringVar(&f.ForgeArtifacts, "forge-artifacts", "", "Forge artifacts directory, to load sourcemaps from, if available")
-	flag.StringVar(&f.OutDir, "out", "", "Output directory to put code in")
-	flag.StringVar(&f.Contracts, "contracts", "artifacts.json", "Path to file containing list of contracts to generate bindings for")
+	var f bindgen.LocalConfig
+	var quiet bool
+	logLevel := oplog.NewLvlFlagValue(log.LvlInfo)
+	logFormat := oplog.NewFormatFlagValue(oplog.FormatText)
+	flag.StringVar(&f.ForgeArtifacts, "forge-artifacts", "", "Forge artifacts directory, or a .zip, .tar.gz or .tgz archive of it")
+	flag.StringVar(&f.OutDir, "out", "", "Output directory to put the metadata files in")
+	flag.StringVar(&f.BindingsOut, "bindings-out", "", "Output directory to put the abigen bindings in, defaults to a directory named after -package in the working directory")
+	flag.StringVar(&f.Contracts, "contracts", "artifacts.json", "Comma-separated paths to files containing lists of contracts to generate bindings for, - reads a list from stdin")
 	flag.StringVar(&f.SourceMaps, "source-maps", "", "Comma-separated list of contracts to generate source-maps for")
+	flag.StringVar(&f.SourceMapMode, "source-map-mode", bindgen.SourceMapModeEmbed, "How deployed source maps are emitted: embed as strings, file to write them to compressed files loaded on demand, or none")
 	flag.StringVar(&f.Package, "package", "artifacts", "Go package name")
+	flag.StringVar(&f.BindingsPackage, "bindings-package", "", "Go package name of the bindings when -bindings-out differs from -out, defaults to -package")
 	flag.StringVar(&f.MonorepoBase, "monorepo-base", "", "Base of the monorepo")
+	flag.IntVar(&f.Concurrency, "concurrency", runtime.GOMAXPROCS(0), "Number of contracts to generate bindings for in parallel")
+	flag.StringVar(&f.Template, "metadata-template", "", "Path to a text/template used to generate the metadata files, defaults to the built-in template")
+	flag.BoolVar(&f.Combined, "combined-metadata", false, "Write the metadata of every contract to a single bindings_more.go, the metadata template is executed once with .Package and .Contracts")
+	flag.BoolVar(&f.NatSpec, "natspec", false, "Add the NatSpec documentation of the contracts to the comments of the bindings")
+	flag.BoolVar(&f.MetadataABI, "metadata-abi", false, "Register the ABI of every contract in the metadata, declaring <Name>ABI when the bindings are written to a different directory")
+	flag.BoolVar(&f.InterfaceOnly, "interface-only", false, "Only generate the bindings of every contract, without their bytecode and without metadata files")
+	flag.BoolVar(&f.AST, "ast", false, "Write the canonicalized AST of every contract to <name>.ast.json next to its metadata")
+	flag.StringVar(&f.AllowedCompilerVersions, "version-check", "", "Comma-separated list of the solc versions the contracts may be compiled with, fails on any other version")
+	flag.BoolVar(&f.CompilerVersionWarn, "version-check-warn", false, "Warn instead of failing when a contract was compiled with a solc version outside of -version-check")
+	flag.BoolVar(&f.Prune, "prune", false, "Delete the generated Go files of contracts that are no longer in the contracts list")
+	flag.StringVar(&f.StorageLayoutDir, "storage-layouts", "", "Directory of <name>.json storage layouts used for the artifacts built without the storageLayout extra output")
+	flag.BoolVar(&f.CompilerSettings, "compiler-settings", false, "Add the compiler version, optimizer settings and EVM version of every contract to the metadata")
+	flag.StringVar(&f.Manifest, "manifest", "", "Path to write a JSON manifest of the generated files and the hashes of their forge artifacts to")
+	flag.StringVar(&f.Summary, "summary", "", "Path to write a JSON summary of the contracts generated, skipped and the bytes written to")
+	flag.BoolVar(&f.Force, "force", false, "Regenerate every contract, instead of skipping the contracts whose generated files record the hash of their current forge artifact, needed after changing the other flags")
+	flag.StringVar(&f.Only, "only", "", "Comma-separated list of contract names or glob patterns to restrict generation to")
+	flag.BoolVar(&f.VerifyMetadata, "verify-metadata", false, "Warn when the metadata hash embedded in the deployed bytecode does not match the artifact metadata")
+	flag.BoolVar(&f.DryRun, "dry-run", false, "Log the files that would be written and how they differ from the existing ones, without writing anything")
+	flag.BoolVar(&f.KeepGoing, "keep-going", false, "Generate every contract that can be generated and report all failures at the end, instead of stopping at the first failure")
+	flag.BoolVar(&f.Check, "check", false, "Compare the generated files with the ones on disk without writing anything, and fail listing the files that differ")
+	flag.BoolVar(&f.CheckDiff, "check-diff", false, "Print a unified diff of every file that differs in check mode, with storage layouts pretty-printed")
+	flag.BoolVar(&f.Strict, "strict", false, "Fail instead of warning when different contracts share the name of the artifact being used")
+	flag.BoolVar(&f.CheckStorage, "check-storage", false, "Fail when a storage layout changes incompatibly with the previously generated one")
+	flag.StringVar(&f.StorageAllow, "storage-allowlist", "", "Comma-separated list of contracts allowed to change their storage layout incompatibly")
+	flag.StringVar(&f.TempDir, "temp-dir", "", "Directory to write the temporary abigen inputs and extracted archives to, defaults to the system temp directory")
+	flag.StringVar(&f.CompilerVersionPattern, "compiler-version-pattern", bindgen.DefaultCompilerVersionPattern, "Regular expression matching the compiler version in artifact file names, which is removed to get the contract name")
+	flag.StringVar(&f.EmptyABIAllow, "empty-abi-allowlist", "", "Comma-separated list of contracts allowed to have an empty ABI")
+	flag.StringVar(&f.FilenameScheme, "filename-scheme", bindgen.FilenameSchemeLower, "How generated file names are derived from contract names: lower, snake or original")
+	flag.Var(logLevel, oplog.LevelFlagName, "The lowest log level that will be output")
+	flag.Var(logFormat, oplog.FormatFlagName, "Format of the log output. Supported formats: 'text', 'terminal', 'logfmt', 'json', 'json-pretty'")
+	flag.BoolVar(&quiet, "quiet", false, "Only log warnings and errors, same as -log.level=warn")
 	flag.Parse()
 
-	if f.MonorepoBase == "" {
-		log.Fatal("must provide -monorepo-base")
-	}
-	log.Printf("Using monorepo base %s\n", f.MonorepoBase)
-
-	contractData, err := os.ReadFile(f.Contracts)
-	if err != nil {
-		log.Fatal("error reading contract list: %w\n", err)
-	}
-	contracts := []string{}
-	if err := json.Unmarshal(contractData, &contracts); err != nil {
-		log.Fatal("error parsing contract list: %w\n", err)
-	}
-
-	sourceMaps := strings.Split(f.SourceMaps, ",")
-	sourceMapsSet := make(map[string]struct{})
-	for _, k := range sourceMaps {
-		sourceMapsSet[k] = struct{}{}
-	}
-
-	if len(contracts) == 0 {
-		log.Fatalf("must define a list of contracts")
-	}
-
-	t := template.Must(template.New("artifact").Parse(tmpl))
-
-	// Make a temp dir to hold all the inputs for abigen
-	dir, err := os.MkdirTemp("", "op-bindings")
-	if err != nil {
-		log.Fatal(err)
-	}
-	log.Printf("Using package %s\n", f.Package)
-
-	defer os.RemoveAll(dir)
-	log.Printf("created temp dir %s\n", dir)
-
-	// If some contracts have the same name then the path to their
-	// artifact depends on their full import path. Scan over all artifacts
-	// and hold a mapping from the contract name to the contract path.
-	// Walk walks the directory deterministically, so the later instance
-	// of the contract with the same name will be used
-	re := regexp.MustCompile(`\.\d+\.\d+\.\d+`)
-	artifactPaths := make(map[string]string)
-	if err := filepath.Walk(f.ForgeArtifacts,
-		func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-
-			if strings.HasSuffix(path, ".json") {
-				base := filepath.Base(path)
-				name := strings.TrimSuffix(base, ".json")
-
-				// remove the compiler version from the name
-				sanitized := re.ReplaceAllString(name, "")
-				if _, ok := artifactPaths[sanitized]; !ok {
-					artifactPaths[sanitized] = path
-				}
-			}
-			return nil
-		}); err != nil {
-		log.Fatal(err)
-	}
-
-	for _, name := range contracts {
-		log.Printf("generating code for %s\n", name)
-
-		artifactPath := path.Join(f.ForgeArtifacts, name+".sol", name+".json")
-		forgeArtifactData, err := os.ReadFile(artifactPath)
-		if errors.Is(err, os.ErrNotExist) {
-			log.Printf("cannot find forge-artifact for %s at standard path %s, trying %s\n", name, artifactPath, artifactPaths[name])
-			artifactPath = artifactPaths[name]
-			forgeArtifactData, err = os.ReadFile(artifactPath)
-			if errors.Is(err, os.ErrNotExist) {
-				log.Fatalf("cannot find forge-artifact of %q\n", name)
-			}
-		}
-
-		log.Printf("using forge-artifact %s\n", artifactPath)
-		var artifact foundry.Artifact
-		if err := json.Unmarshal(forgeArtifactData, &artifact); err != nil {
-			log.Fatalf("failed to parse forge artifact of %q: %v\n", name, err)
-		}
-
-		rawAbi := artifact.Abi
-		if err != nil {
-			log.Fatalf("error marshaling abi: %v\n", err)
-		}
-		abiFile := path.Join(dir, name+".abi")
-		if err := os.WriteFile(abiFile, rawAbi, 0o600); err != nil {
-			log.Fatalf("error writing file: %v\n", err)
-		}
-		rawBytecode := artifact.Bytecode.Object.String()
-		if err != nil {
-			log.Fatalf("error marshaling bytecode: %v\n", err)
-		}
-		bytecodeFile := path.Join(dir, name+".bin")
-		if err := os.WriteFile(bytecodeFile, []byte(rawBytecode), 0o600); err != nil {
-			log.Fatalf("error writing file: %v\n", err)
-		}
-
-		cwd, err := os.Getwd()
-		if err != nil {
-			log.Fatalf("error getting cwd: %v\n", err)
-		}
-
-		lowerName := strings.ToLower(name)
-		outFile := path.Join(cwd, f.Package, lowerName+".go")
-
-		cmd := exec.Command("abigen", "--abi", abiFile, "--bin", bytecodeFile, "--pkg", f.Package, "--type", name, "--out", outFile)
-		cmd.Stdout = os.Stdout
-
-		if err := cmd.Run(); err != nil {
-			log.Fatalf("error running abigen: %v\n", err)
-		}
-
-		storage := artifact.StorageLayout
-		canonicalStorage := ast.CanonicalizeASTIDs(&storage, f.MonorepoBase)
-		ser, err := json.Marshal(canonicalStorage)
-		if err != nil {
-			log.Fatalf("error marshaling storage: %v\n", err)
-		}
-		serStr := strings.Replace(string(ser), "\"", "\\\"", -1)
-
-		deployedSourceMap := ""
-		if _, ok := sourceMapsSet[name]; ok {
-			deployedSourceMap = artifact.DeployedBytecode.SourceMap
-		}
-
-		d := data{
-			Name:              name,
-			StorageLayout:     serStr,
-			DeployedBin:       artifact.DeployedBytecode.Object.String(),
-			Package:           f.Package,
-			DeployedSourceMap: deployedSourceMap,
-		}
-
-		fname := filepath.Join(f.OutDir, strings.ToLower(name)+"_more.go")
-		outfile, err := os.OpenFile(
-			fname,
-			os.O_RDWR|os.O_CREATE|os.O_TRUNC,
-			0o600,
-		)
-		if err != nil {
-			log.Fatalf("error opening %s: %v\n", fname, err)
-		}
-
-		if err := t.Execute(outfile, d); err != nil {
-			log.Fatalf("error writing template %s: %v", outfile.Name(), err)
-		}
-		outfile.Close()
-		log.Printf("wrote file %s\n", outfile.Name())
+	logCfg := oplog.DefaultCLIConfig()
+	logCfg.Level = logLevel.LogLvl()
+	logCfg.Format = logFormat.FormatType()
+	if quiet {
+		logCfg.Level = log.LvlWarn
 	}
-}
-
-var tmpl = `// Code generated - DO NOT EDIT.
-// This file is a generated binding and any manual changes will be lost.
-
-package {{.Package}}
-
-import (
-	"encoding/json"
+	logger := oplog.NewLogger(os.Stdout, logCfg)
 
-	"github.com/ethereum-optimism/optimism/op-bindings/solc"
-)
-
-const {{.Name}}StorageLayoutJSON = "{{.StorageLayout}}"
-
-var {{.Name}}StorageLayout = new(solc.StorageLayout)
-
-var {{.Name}}DeployedBin = "{{.DeployedBin}}"
-{{if .DeployedSourceMap}}
-var {{.Name}}DeployedSourceMap = "{{.DeployedSourceMap}}"
-{{end}}
-func init() {
-	if err := json.Unmarshal([]byte({{.Name}}StorageLayoutJSON), {{.Name}}StorageLayout); err != nil {
-		panic(err)
+	if err := bindgen.GenerateLocal(logger, f); err != nil {
+		logger.Crit("Failed to generate bindings", "err", err)
 	}
-
-	layouts["{{.Name}}"] = {{.Name}}StorageLayout
-	deployedBytecodes["{{.Name}}"] = {{.Name}}DeployedBin
 }
-`
@@ -0,0 +1,148 @@
+package registry
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/solc"
+)
+
+// CompilerSettings is the compiler configuration a contract was built with.
+type CompilerSettings struct {
+	Version          string
+	OptimizerEnabled bool
+	OptimizerRuns    int
+	EVMVersion       string
+}
+
+// Registry holds the metadata of the contracts of a bindings package, keyed by
+// contract name. The bindings packages declare their maps as package variables
+// that the init functions of the generated metadata files populate.
+type Registry struct {
+	Layouts           map[string]*solc.StorageLayout
+	DeployedBytecodes map[string]string
+	// CreationBytecodes holds the creation bytecodes of the contracts that
+	// can be deployed.
+	CreationBytecodes map[string]string
+	// ABIs holds the ABIs of the contracts whose ABI is registered in the
+	// metadata.
+	ABIs map[string]string
+	// Settings holds the compiler settings of the contracts whose settings
+	// are added to the metadata.
+	Settings map[string]CompilerSettings
+	// DeployedSourceMaps holds the gzip compressed deployed source maps that
+	// are generated as separate files.
+	DeployedSourceMaps map[string][]byte
+}
+
+// New returns an empty registry.
+func New() *Registry {
+	return &Registry{
+		Layouts:            make(map[string]*solc.StorageLayout),
+		DeployedBytecodes:  make(map[string]string),
+		CreationBytecodes:  make(map[string]string),
+		ABIs:               make(map[string]string),
+		Settings:           make(map[string]CompilerSettings),
+		DeployedSourceMaps: make(map[string][]byte),
+	}
+}
+
+// StorageLayout returns the storage layout of a contract by name.
+func (r *Registry) StorageLayout(name string) (*solc.StorageLayout, error) {
+	layout := r.Layouts[name]
+	if layout == nil {
+		return nil, fmt.Errorf("%s: storage layout not found", name)
+	}
+	return layout, nil
+}
+
+// DeployedBytecode returns the deployed bytecode of a contract by name.
+func (r *Registry) DeployedBytecode(name string) ([]byte, error) {
+	return bytecode(r.DeployedBytecodes, name, "deployed")
+}
+
+// CreationBytecode returns the creation bytecode of a contract by name.
+func (r *Registry) CreationBytecode(name string) ([]byte, error) {
+	return bytecode(r.CreationBytecodes, name, "creation")
+}
+
+func bytecode(bytecodes map[string]string, name, kind string) ([]byte, error) {
+	bc := bytecodes[name]
+	if bc == "" {
+		return nil, fmt.Errorf("%s: %s bytecode not found", name, kind)
+	}
+
+	if !isHex(bc) {
+		return nil, fmt.Errorf("%s: invalid %s bytecode", name, kind)
+	}
+
+	return common.FromHex(bc), nil
+}
+
+// ABI returns the parsed ABI of a contract by name.
+func (r *Registry) ABI(name string) (*abi.ABI, error) {
+	raw, ok := r.ABIs[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: ABI not found", name)
+	}
+	parsed, err := abi.JSON(strings.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid ABI: %w", name, err)
+	}
+	return &parsed, nil
+}
+
+// CompilerSettings returns the compiler settings of a contract by name.
+func (r *Registry) CompilerSettings(name string) (CompilerSettings, error) {
+	settings, ok := r.Settings[name]
+	if !ok {
+		return CompilerSettings{}, fmt.Errorf("%s: compiler settings not found", name)
+	}
+	return settings, nil
+}
+
+// DeployedSourceMap returns the deployed source map of a contract by name, for
+// contracts whose source map is generated as a separate file.
+func (r *Registry) DeployedSourceMap(name string) (string, error) {
+	compressed := r.DeployedSourceMaps[name]
+	if compressed == nil {
+		return "", fmt.Errorf("%s: deployed source map not found", name)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return "", fmt.Errorf("%s: invalid deployed source map: %w", name, err)
+	}
+	defer gz.Close()
+	sourceMap, err := io.ReadAll(gz)
+	if err != nil {
+		return "", fmt.Errorf("%s: invalid deployed source map: %w", name, err)
+	}
+	return string(sourceMap), nil
+}
+
+// isHexCharacter returns bool of c being a valid hexadecimal.
+func isHexCharacter(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+// isHex validates whether each byte is valid hexadecimal string.
+func isHex(str string) bool {
+	if len(str)%2 != 0 {
+		return false
+	}
+	str = strings.TrimPrefix(str, "0x")
+
+	for _, c := range []byte(str) {
+		if !isHexCharacter(c) {
+			return false
+		}
+	}
+	return true
+}
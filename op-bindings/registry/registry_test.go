@@ -0,0 +1,87 @@
+package registry
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimism/op-bindings/solc"
+)
+
+func TestRegistry(t *testing.T) {
+	r := New()
+	layout := &solc.StorageLayout{}
+	r.Layouts["Foo"] = layout
+	r.DeployedBytecodes["Foo"] = "0x6002"
+	r.CreationBytecodes["Foo"] = "0x6001"
+	r.CreationBytecodes["Bar"] = "0x600"
+	r.ABIs["Foo"] = `[{"type":"fallback","stateMutability":"payable"}]`
+	r.ABIs["Bar"] = `[`
+	r.Settings["Foo"] = CompilerSettings{Version: "0.8.15", OptimizerEnabled: true, OptimizerRuns: 999999, EVMVersion: "london"}
+
+	got, err := r.StorageLayout("Foo")
+	require.NoError(t, err)
+	require.Same(t, layout, got)
+	_, err = r.StorageLayout("Bar")
+	require.EqualError(t, err, "Bar: storage layout not found")
+
+	bc, err := r.DeployedBytecode("Foo")
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x60, 0x02}, bc)
+	_, err = r.DeployedBytecode("Bar")
+	require.EqualError(t, err, "Bar: deployed bytecode not found")
+
+	bc, err = r.CreationBytecode("Foo")
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x60, 0x01}, bc)
+	_, err = r.CreationBytecode("Bar")
+	require.EqualError(t, err, "Bar: invalid creation bytecode")
+
+	parsed, err := r.ABI("Foo")
+	require.NoError(t, err)
+	require.True(t, parsed.HasFallback())
+	_, err = r.ABI("Bar")
+	require.ErrorContains(t, err, "Bar: invalid ABI")
+	_, err = r.ABI("Baz")
+	require.EqualError(t, err, "Baz: ABI not found")
+
+	settings, err := r.CompilerSettings("Foo")
+	require.NoError(t, err)
+	require.Equal(t, "0.8.15", settings.Version)
+	_, err = r.CompilerSettings("Bar")
+	require.EqualError(t, err, "Bar: compiler settings not found")
+}
+
+func TestDeployedSourceMap(t *testing.T) {
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	_, err := w.Write([]byte("1:2:0:-:0"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	r := New()
+	r.DeployedSourceMaps["Foo"] = compressed.Bytes()
+	r.DeployedSourceMaps["Bar"] = []byte("not gzip")
+	sourceMap, err := r.DeployedSourceMap("Foo")
+	require.NoError(t, err)
+	require.Equal(t, "1:2:0:-:0", sourceMap)
+	_, err = r.DeployedSourceMap("Bar")
+	require.ErrorContains(t, err, "Bar: invalid deployed source map")
+	_, err = r.DeployedSourceMap("Baz")
+	require.EqualError(t, err, "Baz: deployed source map not found")
+}
+
+func TestIsHex(t *testing.T) {
+	for str, expected := range map[string]bool{
+		"0x6001": true,
+		"6001":   true,
+		"0xaBcD": true,
+		"0x600":  false,
+		"0x60zz": false,
+		"":       true,
+	} {
+		require.Equal(t, expected, isHex(str), str)
+	}
+}